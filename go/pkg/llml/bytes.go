@@ -0,0 +1,112 @@
+package llml
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"unicode/utf8"
+)
+
+// BytesStyle controls how byte slices and arrays, such as []byte,
+// json.RawMessage and [32]byte, are rendered
+type BytesStyle int
+
+const (
+	// BytesAsText renders bytes as a string when they are valid UTF-8 and as
+	// base64 otherwise (the default)
+	BytesAsText BytesStyle = iota
+	// BytesAsBase64 renders bytes in standard base64 encoding
+	BytesAsBase64
+	// BytesAsHex renders bytes as lowercase hexadecimal
+	BytesAsHex
+	// BytesAsSize renders only the length, as an empty element such as
+	// <payload bytes="2048"/>
+	BytesAsSize
+)
+
+// bytesOf returns the contents of a byte slice or array, including named
+// types such as json.RawMessage or a [32]byte hash. Named types that render
+// themselves through a method (e.g. net.IP, a Stringer) are left to it.
+func bytesOf(value any) ([]byte, bool) {
+	switch v := value.(type) {
+	case []byte:
+		return v, true
+	case json.RawMessage:
+		// Newer Go versions give it a String method; the raw JSON is the content
+		return v, true
+	case LLMLMarshaler, LLMLValuer, encoding.TextMarshaler, error, fmt.Stringer:
+		return nil, false
+	}
+	return byteContents(value)
+}
+
+// byteContents returns the contents of any byte slice or array, regardless
+// of its methods
+func byteContents(value any) ([]byte, bool) {
+	rv := reflect.ValueOf(value)
+	if !rv.IsValid() || !isByteType(rv.Type()) {
+		return nil, false
+	}
+	if b, ok := value.([]byte); ok {
+		return b, true
+	}
+	b := make([]byte, rv.Len())
+	for i := range b {
+		b[i] = byte(rv.Index(i).Uint())
+	}
+	return b, true
+}
+
+// byteType is the element type of byte slices and arrays
+var byteType = reflect.TypeOf(byte(0))
+
+// isByteType reports whether t is a slice or array of bytes, rendered whole
+// rather than item by item. Slices of named uint8 types, such as an enum,
+// hold numbers rather than bytes and are lists.
+func isByteType(t reflect.Type) bool {
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+	return t.Elem() == byteType
+}
+
+func isBytes(value any) bool {
+	_, ok := bytesOf(value)
+	return ok
+}
+
+// formatBytes renders bytes under the configured BytesStyle. Under
+// BytesAsSize the element is left empty and the length is written by
+// bytesAttributes.
+func formatBytes(value any, opts Options) string {
+	b, _ := byteContents(value)
+	switch opts.Bytes {
+	case BytesAsBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case BytesAsHex:
+		return hex.EncodeToString(b)
+	case BytesAsSize:
+		return ""
+	default:
+		if utf8.Valid(b) {
+			return formatString(string(b), opts.Indent)
+		}
+		return base64.StdEncoding.EncodeToString(b)
+	}
+}
+
+// bytesAttributes returns the bytes attribute recorded under BytesAsSize
+func bytesAttributes(value any, opts Options) string {
+	if opts.Bytes != BytesAsSize {
+		return ""
+	}
+	b, ok := bytesOf(indirect(value))
+	if !ok {
+		return ""
+	}
+	return formatAttr("bytes", strconv.Itoa(len(b)), opts)
+}
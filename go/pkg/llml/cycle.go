@@ -0,0 +1,154 @@
+package llml
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// CycleStyle controls how values that refer back to one of their ancestors are handled
+type CycleStyle int
+
+const (
+	// CycleAsRef renders a <ref path="..."/> placeholder pointing at the ancestor (the default)
+	CycleAsRef CycleStyle = iota
+	// CycleAsError omits the element closing the cycle; Marshal returns a
+	// *CycleError for it, while Sprintf drops the element silently
+	CycleAsError
+)
+
+// rootPath is the path of the value passed to Sprintf
+const rootPath = "$"
+
+// CycleError reports a value that refers back to one of its ancestors
+type CycleError struct {
+	// Path is where the cycle was found, e.g. "$.tasks.1.parent"
+	Path string
+	// Ref is the path of the ancestor the value refers back to
+	Ref string
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("cycle detected at %s (refers back to %s)", e.Path, e.Ref)
+}
+
+// visitKey identifies a pointer, map or slice being rendered
+type visitKey struct {
+	ptr uintptr
+	typ reflect.Type
+	len int
+}
+
+// encodeState holds the traversal state shared by a single Sprintf call
+type encodeState struct {
+	ancestors map[visitKey]string
+	errs      []error
+	// hasUntrusted records that an Untrusted leaf was rendered. Once
+	// tagsKnown is set, forged matches the tags of the whole document (or is
+	// nil if it has none) and untrusted leaves are neutralised against it.
+	hasUntrusted bool
+	tagsKnown    bool
+	forged       *regexp.Regexp
+	// nonce suffixes the tags of Fenced sections, once one has been rendered;
+	// nonceFailed records that no randomness was available for it
+	nonce       string
+	nonceFailed bool
+	// pseudonyms holds the placeholders of personal data masked so far
+	pseudonyms pseudonyms
+}
+
+func newEncodeState() *encodeState {
+	return &encodeState{ancestors: make(map[visitKey]string)}
+}
+
+// enter marks value as being rendered at path. If value is already being
+// rendered further up the tree it returns the ancestor's path and false.
+// Values without a reference identity (plain structs, primitives) are never cyclic.
+func (s *encodeState) enter(value any, path string) (string, bool) {
+	key, ok := identity(value)
+	if !ok {
+		return "", true
+	}
+	if ref, seen := s.ancestors[key]; seen {
+		return ref, false
+	}
+	s.ancestors[key] = path
+	return "", true
+}
+
+// leave unmarks a value previously passed to enter
+func (s *encodeState) leave(value any) {
+	if key, ok := identity(value); ok {
+		delete(s.ancestors, key)
+	}
+}
+
+// fail records an error encountered during rendering
+func (s *encodeState) fail(err error) {
+	s.errs = append(s.errs, err)
+}
+
+// err returns the first error recorded during rendering
+func (s *encodeState) err() error {
+	if len(s.errs) == 0 {
+		return nil
+	}
+	return s.errs[0]
+}
+
+// identity returns the reference identity of value: the innermost pointer it
+// dereferences through, or the underlying map or slice
+func identity(value any) (visitKey, bool) {
+	rv := reflect.ValueOf(value)
+	var key visitKey
+	found := false
+	for {
+		switch rv.Kind() {
+		case reflect.Pointer:
+			if rv.IsNil() {
+				return visitKey{}, false
+			}
+			// Pointers to zero-sized values may share an address
+			if rv.Type().Elem().Size() > 0 {
+				key = visitKey{ptr: rv.Pointer(), typ: rv.Type()}
+				found = true
+			}
+			rv = rv.Elem()
+			continue
+		case reflect.Interface:
+			if rv.IsNil() {
+				return visitKey{}, false
+			}
+			rv = rv.Elem()
+			continue
+		case reflect.Map:
+			if rv.IsNil() {
+				return visitKey{}, false
+			}
+			return visitKey{ptr: rv.Pointer(), typ: rv.Type()}, true
+		case reflect.Slice:
+			// Empty slices cannot contain themselves and may share a backing array
+			if rv.Len() == 0 {
+				return key, found
+			}
+			return visitKey{ptr: rv.Pointer(), typ: rv.Type(), len: rv.Len()}, true
+		}
+		return key, found
+	}
+}
+
+// formatCycle renders the element tag for a value found at path that refers
+// back to the ancestor at ref. In CycleAsError mode the error is recorded and
+// the element is omitted.
+func formatCycle(tag, ref, path, indent string, opts Options) string {
+	if opts.Cycles == CycleAsError {
+		opts.state.fail(&MarshalError{Path: path, Err: &CycleError{Path: path, Ref: ref}})
+		return ""
+	}
+	return fmt.Sprintf("%s<%s><ref%s/></%s>", indent, tag, formatAttr("path", ref, opts), tag)
+}
+
+// joinPath appends a key or item number to a traversal path
+func joinPath(path, key string) string {
+	return path + "." + key
+}
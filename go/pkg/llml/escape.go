@@ -0,0 +1,58 @@
+package llml
+
+import (
+	"strings"
+)
+
+// EscapeStyle controls how markup characters in text and attribute values
+// are escaped
+type EscapeStyle int
+
+const (
+	// EscapeNone writes text verbatim (the default)
+	EscapeNone EscapeStyle = iota
+	// EscapeMinimal escapes &, < and > as XML entities
+	EscapeMinimal
+	// EscapeFull escapes &, <, >, " and ' as XML entities
+	EscapeFull
+	// EscapeCDATA wraps text containing &, < or > in a CDATA section.
+	// Attribute values, which cannot hold CDATA, are escaped as with EscapeFull.
+	EscapeCDATA
+)
+
+var (
+	minimalEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+	fullEscaper    = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+)
+
+// escapeText escapes the text of a leaf element under Options.Escape
+func escapeText(s string, opts Options) string {
+	switch opts.Escape {
+	case EscapeMinimal:
+		return minimalEscaper.Replace(s)
+	case EscapeFull:
+		return fullEscaper.Replace(s)
+	case EscapeCDATA:
+		if !strings.ContainsAny(s, "&<>") {
+			return s
+		}
+		// "]]>" would end the section early, so split it across two sections
+		return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") + "]]>"
+	default:
+		return s
+	}
+}
+
+// formatAttr renders an attribute with a leading space, e.g. ` path="$"`.
+// Values are escaped under Options.Escape; quotes are always escaped unless
+// escaping is disabled, as attribute values are double-quoted.
+func formatAttr(name, value string, opts Options) string {
+	switch opts.Escape {
+	case EscapeNone:
+	case EscapeMinimal:
+		value = strings.ReplaceAll(minimalEscaper.Replace(value), `"`, "&quot;")
+	default:
+		value = fullEscaper.Replace(value)
+	}
+	return " " + name + `="` + value + `"`
+}
@@ -0,0 +1,106 @@
+package llml
+
+import (
+	"strings"
+	"unicode"
+)
+
+// KeyCase transforms keys into tag names, e.g. KebabCase. It is applied to
+// element tags, list item tags and prefixes; data paths keep the original
+// keys. Prefixed names are transformed as a whole ("parent-childKey"), so
+// functions should be idempotent.
+type KeyCase func(key string) string
+
+// KebabCase converts key to kebab-case: "XMLHttpRequest" → "xml-http-request"
+func KebabCase(key string) string {
+	return joinWords(key, "-")
+}
+
+// SnakeCase converts key to snake_case: "XMLHttpRequest" → "xml_http_request"
+func SnakeCase(key string) string {
+	return joinWords(key, "_")
+}
+
+// CamelCase converts key to camelCase: "user_name" → "userName" and
+// "XMLHttpRequest" → "xmlHttpRequest"
+func CamelCase(key string) string {
+	words := splitWords(key)
+	if len(words) == 0 {
+		return key
+	}
+	var b strings.Builder
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 {
+			r := []rune(word)
+			r[0] = unicode.ToUpper(r[0])
+			word = string(r)
+		}
+		b.WriteString(word)
+	}
+	return b.String()
+}
+
+// joinWords lowercases the words of key and joins them with sep. Keys
+// without words (e.g. "--") are returned unchanged.
+func joinWords(key, sep string) string {
+	words := splitWords(key)
+	if len(words) == 0 {
+		return key
+	}
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, sep)
+}
+
+// splitWords splits key into words at separators (any rune that is neither a
+// letter nor a digit) and case changes. Acronyms stay together, so
+// "XMLHttpRequest" splits into "XML", "Http", "Request" and "userID" into
+// "user", "ID". Digits stay attached to the word they follow.
+func splitWords(key string) []string {
+	var words []string
+	runes := []rune(key)
+	start := -1
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if start >= 0 {
+				words = append(words, string(runes[start:i]))
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+			continue
+		}
+
+		prev := runes[i-1]
+		boundary := unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev))
+		// The last capital of an acronym starts the next word: "XMLHttp" → "XML", "Http"
+		if unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+			boundary = true
+		}
+		if boundary {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, string(runes[start:]))
+	}
+	return words
+}
+
+// joinTag builds the tag name for key under prefix, applying Options.KeyCase
+// and then Options.TagNames
+func joinTag(prefix, key string, opts Options) string {
+	name := key
+	if prefix != "" {
+		name = prefix + "-" + key
+	}
+	if opts.KeyCase != nil {
+		name = opts.KeyCase(name)
+	}
+	return opts.TagNames.sanitize(name)
+}
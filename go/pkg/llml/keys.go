@@ -0,0 +1,186 @@
+package llml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// isMapKeyType reports whether map keys of type t can be rendered as tag
+// names: string kinds, encoding.TextMarshalers and integers, in that order of
+// precedence as with encoding/json
+func isMapKeyType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+	return t.Implements(textMarshalerType)
+}
+
+// mapKey is a map key together with its tag name
+type mapKey struct {
+	name  string
+	value reflect.Value
+}
+
+// mapEntries returns the key-value pairs of a map sorted by Options.KeyOrder,
+// or by default integer keys numerically and all others lexically. Keys the
+// KeyOrder reports as equal fall back to the default order, so the output
+// does not depend on map iteration order.
+func mapEntries(v reflect.Value, opts Options) []entry {
+	keys := make([]mapKey, 0, v.Len())
+	iter := v.MapRange()
+	for iter.Next() {
+		keys = append(keys, mapKey{name: keyName(iter.Key(), opts), value: iter.Key()})
+	}
+
+	numeric := v.Type().Key().Kind() != reflect.String && !v.Type().Key().Implements(textMarshalerType)
+	sort.Slice(keys, func(i, j int) bool {
+		if opts.KeyOrder != nil {
+			if c := opts.KeyOrder(keys[i].name, keys[j].name); c != 0 {
+				return c < 0
+			}
+		}
+		if numeric {
+			return lessNumeric(keys[i].value, keys[j].value)
+		}
+		return keys[i].name < keys[j].name
+	})
+
+	entries := make([]entry, 0, len(keys))
+	for _, k := range keys {
+		entries = append(entries, entry{key: k.name, value: v.MapIndex(k.value).Interface()})
+	}
+	return entries
+}
+
+// keyName converts a map key to a tag name. TextMarshaler errors are
+// recorded and the key falls back to %v.
+func keyName(k reflect.Value, opts Options) string {
+	if k.Kind() == reflect.String {
+		return k.String()
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		if k.Kind() == reflect.Pointer && k.IsNil() {
+			return ""
+		}
+		text, err := tm.MarshalText()
+		if err != nil {
+			name := fmt.Sprintf("%v", k.Interface())
+			opts.state.fail(&MarshalError{Path: joinPath(opts.path, name), Err: err})
+			return name
+		}
+		return string(text)
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10)
+	default:
+		return strconv.FormatUint(k.Uint(), 10)
+	}
+}
+
+// lessNumeric orders two integer keys of the same kind
+func lessNumeric(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return a.Int() < b.Int()
+	default:
+		return a.Uint() < b.Uint()
+	}
+}
+
+// KeyOrder compares two map keys, returning a negative number when a renders
+// before b, a positive number when after, and zero when it has no preference.
+// Keys compared as zero keep the default order.
+type KeyOrder func(a, b string) int
+
+// LexicalOrder sorts keys by byte-wise comparison, e.g. "item10" before
+// "item2". It is the default for maps with string keys.
+func LexicalOrder(a, b string) int {
+	return strings.Compare(a, b)
+}
+
+// NaturalOrder sorts keys comparing runs of digits numerically, so "item2"
+// renders before "item10"
+func NaturalOrder(a, b string) int {
+	restA, restB := a, b
+	for restA != "" && restB != "" {
+		var ca, cb string
+		ca, restA = leadingChunk(restA)
+		cb, restB = leadingChunk(restB)
+		if c := compareChunks(ca, cb); c != 0 {
+			return c
+		}
+	}
+
+	// A key that is a prefix of the other renders first
+	switch {
+	case restA == "" && restB != "":
+		return -1
+	case restA != "" && restB == "":
+		return 1
+	}
+	// Keys differing only in leading zeros (e.g. "01" and "1") fall back to lexical order
+	return strings.Compare(a, b)
+}
+
+// leadingChunk splits s after its leading run of digits or non-digits
+func leadingChunk(s string) (string, string) {
+	digits := isDigit(s[0])
+	i := 1
+	for i < len(s) && isDigit(s[i]) == digits {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// compareChunks compares two runs numerically if both are digits and
+// lexically otherwise
+func compareChunks(a, b string) int {
+	if !isDigit(a[0]) || !isDigit(b[0]) {
+		return strings.Compare(a, b)
+	}
+	ta, tb := strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(ta) != len(tb) {
+		return len(ta) - len(tb)
+	}
+	return strings.Compare(ta, tb)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// PriorityOrder returns a KeyOrder that renders keys in the given order ahead
+// of all others, which follow in lexical order:
+//
+//	llml.Options{KeyOrder: llml.PriorityOrder("role", "task", "context")}
+func PriorityOrder(keys ...string) KeyOrder {
+	rank := make(map[string]int, len(keys))
+	for i, key := range keys {
+		if _, seen := rank[key]; !seen {
+			rank[key] = i
+		}
+	}
+	return func(a, b string) int {
+		ra, okA := rank[a]
+		rb, okB := rank[b]
+		switch {
+		case okA && okB:
+			return ra - rb
+		case okA:
+			return -1
+		case okB:
+			return 1
+		}
+		return strings.Compare(a, b)
+	}
+}
@@ -2,9 +2,11 @@ package llml
 
 import (
 	"fmt"
-	"sort"
+	"io"
+	"reflect"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Options holds configuration for LLML formatting
@@ -12,8 +14,136 @@ type Options struct {
 	Indent string
 	Prefix string
 	Strict bool
+
+	// TagKeys lists the struct tag keys consulted, in order of precedence,
+	// when naming struct fields. The first key present on a field decides its
+	// name and omitempty behaviour. Defaults to []string{"llml"}; use
+	// []string{"llml", "json", "xml"} to reuse existing encoding tags.
+	TagKeys []string
+
+	// KeyOrder sorts the keys of maps, e.g. NaturalOrder or
+	// PriorityOrder("role", "task", "context"). Defaults to lexical order,
+	// with integer keys in numeric order. Structs keep their field order and
+	// Ordered its insertion order.
+	KeyOrder KeyOrder
+
+	// KeyCase transforms tag names, e.g. KebabCase, SnakeCase, CamelCase or
+	// a custom func. It applies to complete tag names, including prefixes
+	// and list item numbers. Defaults to nil, which keeps keys verbatim.
+	KeyCase KeyCase
+
+	// TagNames rewrites names that would not form well-formed tags, e.g.
+	// TagPolicy{Replacement: "-"}. Defaults to writing names verbatim.
+	TagNames TagPolicy
+
+	// Escape controls how markup characters in text and attribute values
+	// are escaped. Defaults to EscapeNone.
+	Escape EscapeStyle
+
+	// Nil controls how nil values are rendered, including typed nil pointers,
+	// nil maps and nil slices. Defaults to NilAsNil.
+	Nil NilStyle
+
+	// Cycles controls what happens when a value refers back to one of its
+	// ancestors. Defaults to CycleAsRef.
+	Cycles CycleStyle
+
+	// Floats controls the precision and notation of floating point values,
+	// including *big.Float and *big.Rat. The zero value renders the shortest
+	// representation that round-trips.
+	Floats FloatFormat
+
+	// NaN controls how NaN and infinite floats are handled. Defaults to NaNAsText.
+	NaN NaNStyle
+
+	// Bytes controls how byte slices and arrays, such as []byte,
+	// json.RawMessage and [32]byte, are rendered.
+	// Defaults to BytesAsText.
+	Bytes BytesStyle
+
+	// TimeLayout is the layout used to render time.Time values, e.g.
+	// time.DateOnly or "Jan 2, 2006 15:04". Defaults to time.RFC3339.
+	TimeLayout string
+
+	// TimeZone, if set, converts time.Time values to this location before
+	// they are rendered, e.g. time.UTC to normalise timestamps.
+	TimeZone *time.Location
+
+	// Times controls whether time.Time values render as timestamps or
+	// relative to Now, e.g. "3 hours ago". Defaults to TimeAsAbsolute.
+	Times TimeStyle
+
+	// Now returns the reference time for relative times. Defaults to
+	// time.Now; inject a fixed clock to keep output deterministic.
+	Now func() time.Time
+
+	// Durations controls how time.Duration values are rendered. Defaults to
+	// DurationAsString.
+	Durations DurationStyle
+
+	// Registry holds the formatters used to render values as text. Defaults
+	// to DefaultRegistry(); extend it with Register to override how specific
+	// values render.
+	Registry *Registry
+
+	// TextMethods lists, in order of priority, the text methods used to render
+	// values that implement them. Defaults to TextMarshaler, then error, then
+	// fmt.Stringer; an empty non-nil slice disables them.
+	TextMethods []TextMethod
+
+	// Redact replaces secrets found under matching keys or in the text of
+	// leaves, e.g. DefaultRedaction(). Defaults to redacting nothing.
+	Redact Redaction
+
+	// Detectors find personal data in string leaves and bytes rendered as
+	// text, e.g. DefaultDetectors(). Each finding is replaced by a
+	// placeholder such as [EMAIL_1], the same for every occurrence within a
+	// render, and listed in Report.Pseudonyms.
+	Detectors []Detector
+
+	// Rand is the source of the nonce suffixing the tags of Fenced sections.
+	// Defaults to crypto/rand.Reader; use a seeded math/rand.Rand for
+	// deterministic output in tests. If it fails, crypto/rand.Reader is used
+	// instead and Marshal returns the error.
+	Rand io.Reader
+
+	// Report, if set, receives a description of what the render changed,
+	// such as text neutralised in Untrusted values, the nonce of Fenced
+	// sections and the placeholders of masked personal data
+	Report *Report
+
+	// path, untrusted and state track the traversal; they are set by
+	// Sprintf. Together with the fields above they form the render context
+	// that every formatting function receives, so options apply uniformly at
+	// any depth.
+	path      string
+	untrusted bool
+	state     *encodeState
+}
+
+// nested returns the render context for content found at path, indented by
+// indent and with tag prefix prefix. Every other option carries over unchanged.
+func (o Options) nested(indent, prefix, path string) Options {
+	o.Indent = indent
+	o.Prefix = prefix
+	o.path = path
+	return o
 }
 
+// NilStyle controls how nil values are rendered
+type NilStyle int
+
+const (
+	// NilAsNil renders nil values as "nil" (the default)
+	NilAsNil NilStyle = iota
+	// NilAsNull renders nil values as "null"
+	NilAsNull
+	// NilAsEmpty renders nil values as an empty element
+	NilAsEmpty
+	// NilOmit omits elements whose value is nil
+	NilOmit
+)
+
 // Sprintf converts data structures to XML-like markup using a recursive approach
 // Supports various call patterns:
 //   - Sprintf() -> ""
@@ -27,76 +157,120 @@ func Sprintf(data interface{}, opts ...Options) string {
 		options = opts[0]
 	}
 
-	// Handle nil
+	// Sprintf is lenient: problems reported by Marshal are rendered as best we can
+	result, _ := encode(data, options)
+	return result
+}
+
+// encode renders data with a fresh traversal state and returns the output
+// together with the first error recorded along the way
+func encode(data any, opts Options) (string, error) {
+	// Options handed to a Formatter carry the state of the render they come
+	// from; rendering with them again must not clear that render's report
+	if opts.state == nil {
+		opts.Report.reset()
+	}
+	state := newEncodeState()
+	result := render(data, opts, state)
+	if !state.hasUntrusted {
+		return result, state.err()
+	}
+
+	// Untrusted text is neutralised against every tag of the document, which
+	// are only known once it has been rendered with placeholders in its place.
+	// The second pass shares the nonce, and redactions are reported once.
+	final := newEncodeState()
+	final.nonce = state.nonce
+	final.tagsKnown = true
+	final.forged = forgedTagPattern(result)
+	opts.Redact.OnRedact = nil
+	return render(data, opts, final), state.err()
+}
+
+// render renders data from the root with the traversal state state
+func render(data any, opts Options, state *encodeState) string {
+	opts.state = state
+	opts.path = rootPath
+
+	// Track the root so values referring back to it are detected as cycles
+	if _, ok := state.enter(data, opts.path); ok {
+		defer state.leave(data)
+	}
+	return formatValue(data, opts.taint(data))
+}
+
+// formatValue dispatches on the dynamic type of data
+func formatValue(data any, opts Options) string {
+	// Handle nil, including typed nil pointers, maps and slices
+	data = resolve(data, opts.path, opts)
 	if data == nil {
-		return "nil"
+		return formatNil(opts)
 	}
 
-	// Handle maps (the main case)
-	if m, ok := data.(map[string]any); ok {
-		return formatMap(m, options)
-	}
-
-	// Handle slices
-	if s, ok := data.([]any); ok {
-		return formatSlice(s, options)
-	}
-
-	// Handle primitive types
-	switch v := data.(type) {
-	case string:
-		return formatString(v, options.Indent)
-	case bool:
-		return strconv.FormatBool(v)
-	case int:
-		return strconv.Itoa(v)
-	case int8:
-		return strconv.FormatInt(int64(v), 10)
-	case int16:
-		return strconv.FormatInt(int64(v), 10)
-	case int32:
-		return strconv.FormatInt(int64(v), 10)
-	case int64:
-		return strconv.FormatInt(v, 10)
-	case uint:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint8:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint16:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint32:
-		return strconv.FormatUint(uint64(v), 10)
-	case uint64:
-		return strconv.FormatUint(v, 10)
-	case float32:
-		return strconv.FormatFloat(float64(v), 'g', -1, 32)
-	case float64:
-		return strconv.FormatFloat(v, 'g', -1, 64)
-	default:
-		return fmt.Sprintf("%v", data)
+	// Handle maps (the main case), including typed maps such as map[string]string, and structs
+	rv := reflect.ValueOf(data)
+	if isObjectValue(rv) {
+		return formatMap(rv, opts)
+	}
+
+	// Handle slices and arrays of any element type
+	if isListValue(rv) {
+		return formatSlice(rv, opts)
 	}
+
+	return formatLeaf(data, opts.path, opts)
 }
 
-// formatMap handles the core recursive case: formatting key-value pairs
-func formatMap(m map[string]any, opts Options) string {
-	if len(m) == 0 {
+// formatLeaf renders a resolved value that is neither an object nor a list
+func formatLeaf(value any, path string, opts Options) string {
+	if _, ok := value.(omitted); ok {
 		return ""
 	}
 
-	// Get sorted keys for consistent output
-	keys := make([]string, 0, len(m))
-	for k := range m {
-		keys = append(keys, k)
+	// Leaves have been rendered to text during resolve (see leafValue)
+	text, _ := value.(formatted)
+
+	escaped := escapeText(string(text), opts)
+	if opts.untrusted {
+		return markUntrusted(escaped, path, opts)
 	}
-	sort.Strings(keys)
+	return escaped
+}
 
-	var parts []string
-	for _, key := range keys {
-		value := m[key]
+// leafAttributes returns the attributes written on the opening tag of the
+// element holding the unresolved leaf value raw, including a leading space
+func leafAttributes(raw any, opts Options) string {
+	raw = unwrap(raw)
+	return timeAttributes(raw, opts) + bytesAttributes(raw, opts)
+}
 
+// formatLeafElement writes a leaf element on a single line. Elements carrying
+// attributes but no content are self-closing, e.g. <payload bytes="2048"/>.
+func formatLeafElement(indent, tag, attrs, content string) string {
+	if attrs != "" && content == "" {
+		return fmt.Sprintf("%s<%s%s/>", indent, tag, attrs)
+	}
+	return fmt.Sprintf("%s<%s%s>%s</%s>", indent, tag, attrs, content, tag)
+}
+
+// formatMap handles the core recursive case: formatting key-value pairs.
+// m must be a map with renderable keys, a struct, or an LLMLMarshaler
+// wrapped by resolve (see isObjectValue).
+func formatMap(m reflect.Value, opts Options) string {
+	if mv, ok := m.Interface().(marshalerValue); ok {
+		return formatMarshaler(mv.m, opts)
+	}
+
+	entries := objectEntries(m, opts)
+	if len(entries) == 0 {
+		return ""
+	}
+
+	var parts []string
+	for _, e := range entries {
 		// Recursively format this key-value pair
-		formatted := formatKeyValue(key, value, opts)
-		
+		formatted := formatKeyValue(e.key, e.value, opts)
+
 		// Skip empty results (like empty arrays)
 		if formatted != "" {
 			if len(parts) > 0 {
@@ -111,46 +285,80 @@ func formatMap(m map[string]any, opts Options) string {
 
 // formatKeyValue handles a single key-value pair (the recursive unit)
 func formatKeyValue(key string, value any, opts Options) string {
-	fullKey := key
-	if opts.Prefix != "" {
-		fullKey = opts.Prefix + "-" + key
+	fullKey := joinTag(opts.Prefix, key, opts)
+
+	path := joinPath(opts.path, key)
+	checkTagName(fullKey, path, opts)
+
+	// Fenced sections carry the nonce in their element tag only
+	tag := fenceTag(fullKey, value, path, opts)
+	opts = opts.taint(value)
+	// Secrets are replaced before anything, attributes included, is rendered
+	if opts.Redact.matchesKey(key) {
+		value = redactKey(value, path, opts)
+	}
+	raw := value
+	value = resolve(value, path, opts)
+	rv := reflect.ValueOf(value)
+
+	// Guard against values that refer back to one of their ancestors
+	if isListValue(rv) || isObjectValue(rv) {
+		ref, ok := opts.state.enter(raw, path)
+		if !ok {
+			return formatCycle(tag, ref, path, opts.Indent, opts)
+		}
+		defer opts.state.leave(raw)
 	}
 
 	// Handle lists with wrapper tags
-	if slice, ok := value.([]any); ok {
-		return formatList(slice, key, opts)
+	if isListValue(rv) {
+		return formatList(rv, key, tag, opts)
 	}
 
-	// Handle nested maps
-	if nested, ok := value.(map[string]any); ok {
-		return formatNestedMap(nested, fullKey, fullKey, opts)
+	// Handle nested maps and structs
+	if isObjectValue(rv) {
+		return formatNestedMap(rv, tag, fullKey, opts.nested(opts.Indent, opts.Prefix, path))
+	}
+
+	// Handle nil and omitted values
+	if value == nil && opts.Nil == NilOmit {
+		return ""
+	}
+	if _, ok := value.(omitted); ok {
+		return ""
 	}
 
 	// Handle primitive values
-	formatted := Sprintf(value)
+	formatted := formatNil(opts)
+	if value != nil {
+		formatted = formatLeaf(value, path, opts)
+	}
+	attrs := leafAttributes(raw, opts)
 	if strings.Contains(formatted, "\n") {
-		return fmt.Sprintf("%s<%s>\n%s\n%s</%s>",
-			opts.Indent, fullKey, formatted, opts.Indent, fullKey)
+		return fmt.Sprintf("%s<%s%s>\n%s\n%s</%s>",
+			opts.Indent, tag, attrs, formatted, opts.Indent, tag)
 	}
-	return fmt.Sprintf("%s<%s>%s</%s>",
-		opts.Indent, fullKey, formatted, fullKey)
+	return formatLeafElement(opts.Indent, tag, attrs, formatted)
+}
+
+// isTextContent reports whether content, rendered at indent for the object
+// v, is a single line of text written by an LLMLMarshaler, such as
+// enc.Encode("$1.00"), rather than elements. Text stays inline like a leaf,
+// e.g. <prices-1>$1.00</prices-1>; elements always start with the indent.
+func isTextContent(v reflect.Value, content, indent string) bool {
+	return isMarshalerValue(v) && content != "" &&
+		!strings.Contains(content, "\n") && !strings.HasPrefix(content, indent)
 }
 
 // formatNestedMap handles nested map formatting
-func formatNestedMap(nested map[string]any, key, fullKey string, opts Options) string {
-	nestedOpts := Options{
-		Indent: opts.Indent + "  ",
-		Strict: opts.Strict,
-	}
-	
+func formatNestedMap(nested reflect.Value, key, fullKey string, opts Options) string {
 	// In strict mode, use parent key as prefix. In non-strict mode, don't use prefix
+	prefix := ""
 	if opts.Strict {
-		nestedOpts.Prefix = fullKey
-	} else {
-		nestedOpts.Prefix = ""
+		prefix = fullKey
 	}
-	
-	content := Sprintf(nested, nestedOpts)
+
+	content := formatMap(nested, opts.nested(opts.Indent+"  ", prefix, opts.path))
 
 	if strings.Contains(content, "\n") {
 		return fmt.Sprintf("%s<%s>\n%s\n%s</%s>",
@@ -160,15 +368,12 @@ func formatNestedMap(nested map[string]any, key, fullKey string, opts Options) s
 		opts.Indent, key, content, key)
 }
 
-// formatList handles list formatting with wrapper tags
-func formatList(items []any, key string, opts Options) string {
-	fullKey := key
-	if opts.Prefix != "" {
-		fullKey = opts.Prefix + "-" + key
-	}
-	wrapperTag := fullKey
+// formatList handles list formatting with wrapper tags.
+// items must be a slice or array (see isListValue).
+func formatList(items reflect.Value, key, wrapperTag string, opts Options) string {
+	fullKey := joinTag(opts.Prefix, key, opts)
 
-	if len(items) == 0 {
+	if items.Len() == 0 {
 		return ""
 	}
 
@@ -176,30 +381,65 @@ func formatList(items []any, key string, opts Options) string {
 	parts = append(parts, fmt.Sprintf("%s<%s>\n", opts.Indent, wrapperTag))
 
 	innerIndent := opts.Indent + "  "
-	for i, item := range items {
-		itemTag := fmt.Sprintf("%s-%d", fullKey, i+1)
+	listPath := joinPath(opts.path, key)
+	for i := 0; i < items.Len(); i++ {
+		itemTag := joinTag(fullKey, strconv.Itoa(i+1), opts)
+		itemPath := joinPath(listPath, strconv.Itoa(i+1))
+		raw := items.Index(i).Interface()
+		// Items wrapped by Untrusted or Fenced guard their own content only
+		tag := fenceTag(itemTag, raw, itemPath, opts)
+		opts := opts.taint(raw)
+		item := resolve(raw, itemPath, opts)
+
+		// Guard against items that refer back to one of their ancestors
+		rv := reflect.ValueOf(item)
+		entered := false
+		if isListValue(rv) || isObjectValue(rv) {
+			ref, ok := opts.state.enter(raw, itemPath)
+			if !ok {
+				if cycle := formatCycle(tag, ref, itemPath, innerIndent, opts); cycle != "" {
+					parts = append(parts, cycle+"\n")
+				}
+				continue
+			}
+			entered = true
+		}
 
 		// Handle dictionary items
-		if dict, ok := item.(map[string]any); ok {
-			parts = append(parts, fmt.Sprintf("%s<%s>\n", innerIndent, itemTag))
-			nestedOpts := Options{
-				Indent: innerIndent + "  ",
-				Strict: opts.Strict,
-			}
+		if dict := rv; isObjectValue(dict) {
 			// In strict mode, use array item tag as prefix. In non-strict mode, don't use prefix
+			prefix := ""
 			if opts.Strict {
-				nestedOpts.Prefix = itemTag
+				prefix = itemTag
+			}
+			content := formatMap(dict, opts.nested(innerIndent+"  ", prefix, itemPath))
+			if isTextContent(dict, content, innerIndent+"  ") {
+				parts = append(parts, formatLeafElement(innerIndent, tag, "", content)+"\n")
 			} else {
-				nestedOpts.Prefix = ""
+				parts = append(parts, fmt.Sprintf("%s<%s>\n%s\n%s</%s>\n",
+					innerIndent, tag, content, innerIndent, tag))
+			}
+		} else if item == nil {
+			// Handle nil items
+			if opts.Nil != NilOmit {
+				parts = append(parts, fmt.Sprintf("%s<%s>%s</%s>\n",
+					innerIndent, tag, formatNil(opts), tag))
 			}
-			content := Sprintf(dict, nestedOpts)
-			parts = append(parts, content)
-			parts = append(parts, fmt.Sprintf("\n%s</%s>\n", innerIndent, itemTag))
+		} else if _, ok := item.(omitted); ok {
+			// Omitted items are skipped
+		} else if isListValue(rv) {
+			// Handle nested list items inline with numeric tags
+			formatted := formatSlice(rv, opts.nested("", "", itemPath))
+			parts = append(parts, fmt.Sprintf("%s<%s>%s</%s>\n",
+				innerIndent, tag, formatted, tag))
 		} else {
 			// Handle simple items
-			formatted := Sprintf(item)
-			parts = append(parts, fmt.Sprintf("%s<%s>%s</%s>\n",
-				innerIndent, itemTag, formatted, itemTag))
+			formatted := formatLeaf(item, itemPath, opts)
+			parts = append(parts, formatLeafElement(innerIndent, tag, leafAttributes(raw, opts), formatted)+"\n")
+		}
+
+		if entered {
+			opts.state.leave(raw)
 		}
 	}
 
@@ -207,64 +447,75 @@ func formatList(items []any, key string, opts Options) string {
 	return strings.Join(parts, "")
 }
 
-// formatSlice handles direct slice calls with numeric tags
-func formatSlice(items []any, opts Options) string {
-	if len(items) == 0 {
+// formatSlice handles direct slice calls with numeric tags.
+// items must be a slice or array (see isListValue).
+func formatSlice(items reflect.Value, opts Options) string {
+	if items.Len() == 0 {
 		return ""
 	}
 
 	var parts []string
-	for i, item := range items {
-		itemTag := strconv.Itoa(i + 1)
-		if opts.Prefix != "" {
-			itemTag = opts.Prefix + "-" + itemTag
-		}
-
-		// Handle dictionary items in direct arrays
-		if dict, ok := item.(map[string]any); ok {
-			var content string
-			if len(dict) == 0 {
-				content = ""
-			} else {
-				nestedOpts := Options{
-					Indent: opts.Indent + "  ",
-					Prefix: itemTag,
-					Strict: opts.Strict,
+	for i := 0; i < items.Len(); i++ {
+		itemTag := joinTag(opts.Prefix, strconv.Itoa(i+1), opts)
+		itemPath := joinPath(opts.path, strconv.Itoa(i+1))
+		raw := items.Index(i).Interface()
+		// Items wrapped by Untrusted or Fenced guard their own content only
+		tag := fenceTag(itemTag, raw, itemPath, opts)
+		opts := opts.taint(raw)
+		item := resolve(raw, itemPath, opts)
+
+		// Guard against items that refer back to one of their ancestors
+		rv := reflect.ValueOf(item)
+		entered := false
+		if isListValue(rv) || isObjectValue(rv) {
+			ref, ok := opts.state.enter(raw, itemPath)
+			if !ok {
+				if cycle := formatCycle(tag, ref, itemPath, opts.Indent, opts); cycle != "" {
+					parts = append(parts, cycle)
 				}
-				content = Sprintf(dict, nestedOpts)
+				continue
 			}
-			
-			if content == "" {
-				parts = append(parts, fmt.Sprintf("%s<%s></%s>", opts.Indent, itemTag, itemTag))
+			entered = true
+		}
+
+		if isObjectValue(rv) {
+			// Handle dictionary items in direct arrays
+			content := formatMap(rv, opts.nested(opts.Indent+"  ", itemTag, itemPath))
+
+			if content == "" || isTextContent(rv, content, opts.Indent+"  ") {
+				parts = append(parts, fmt.Sprintf("%s<%s>%s</%s>", opts.Indent, tag, content, tag))
 			} else {
 				// Force multiline format for objects in direct arrays
 				parts = append(parts, fmt.Sprintf("%s<%s>\n%s\n%s</%s>",
-					opts.Indent, itemTag, content, opts.Indent, itemTag))
+					opts.Indent, tag, content, opts.Indent, tag))
 			}
-		} else if slice, ok := item.([]any); ok {
+		} else if isListValue(rv) {
 			// Handle array items in direct arrays - skip empty arrays
-			if len(slice) > 0 {
+			if rv.Len() > 0 {
 				// For non-empty arrays, format recursively
-				nestedResult := formatSlice(slice, Options{
-					Indent: opts.Indent + "  ",
-					Prefix: "",
-					Strict: opts.Strict,
-				})
+				nestedResult := formatSlice(rv, opts.nested(opts.Indent+"  ", "", itemPath))
 				if nestedResult != "" {
 					parts = append(parts, fmt.Sprintf("%s<%s>\n%s\n%s</%s>",
-						opts.Indent, itemTag, nestedResult, opts.Indent, itemTag))
+						opts.Indent, tag, nestedResult, opts.Indent, tag))
 				}
 			}
 			// Empty arrays are skipped implicitly
 		} else {
 			// Handle simple items
-			formatted := Sprintf(item)
-			if formatted != "" {
-				parts = append(parts, fmt.Sprintf("%s<%s>%s</%s>",
-					opts.Indent, itemTag, formatted, itemTag))
+			formatted := formatNil(opts)
+			if item != nil {
+				formatted = formatLeaf(item, itemPath, opts)
+			}
+			attrs := leafAttributes(raw, opts)
+			if formatted != "" || attrs != "" {
+				parts = append(parts, formatLeafElement(opts.Indent, tag, attrs, formatted))
 			}
 			// Empty items are skipped implicitly
 		}
+
+		if entered {
+			opts.state.leave(raw)
+		}
 	}
 
 	// If all items were skipped, return empty string
@@ -275,6 +526,98 @@ func formatSlice(items []any, opts Options) string {
 	return strings.Join(parts, "\n")
 }
 
+// entry is a single key-value pair of a map or struct, in render order
+type entry struct {
+	key   string
+	value any
+}
+
+// objectEntries returns the key-value pairs of a map (sorted by key for
+// consistent output) or a struct (in field declaration order)
+func objectEntries(v reflect.Value, opts Options) []entry {
+	if v.Kind() == reflect.Struct {
+		return structEntries(v, opts.TagKeys)
+	}
+
+	return mapEntries(v, opts)
+}
+
+// isObjectValue reports whether v renders as nested key-value elements:
+// maps with renderable keys, structs with exported fields, and LLMLMarshalers
+func isObjectValue(v reflect.Value) bool {
+	return isMapValue(v) || isStructValue(v) || isMarshalerValue(v)
+}
+
+// isMapValue reports whether v is a map whose keys can be rendered as tag
+// names: strings, integers or encoding.TextMarshalers (see isMapKeyType)
+func isMapValue(v reflect.Value) bool {
+	return v.Kind() == reflect.Map && isMapKeyType(v.Type().Key())
+}
+
+// isListValue reports whether v is a slice or array that should be rendered item by item.
+// Byte slices and arrays are left to the byte formatter (see isByteType).
+func isListValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		return !isByteType(v.Type())
+	default:
+		return false
+	}
+}
+
+// formatNil returns the text for a nil value under the configured NilStyle.
+// NilOmit is handled by the callers, which drop the element entirely.
+func formatNil(opts Options) string {
+	switch opts.Nil {
+	case NilAsNull:
+		return "null"
+	case NilAsEmpty, NilOmit:
+		return ""
+	default:
+		return "nil"
+	}
+}
+
+// omitted marks a value whose element is dropped, such as NaN under NaNOmit
+type omitted struct{}
+
+// unwrap returns the value wrapped by Untrusted and Fenced, in any nesting
+func unwrap(value any) any {
+	for {
+		switch v := value.(type) {
+		case untrusted:
+			value = v.value
+		case fenced:
+			value = v.value
+		default:
+			return value
+		}
+	}
+}
+
+// indirect dereferences pointers and interfaces until it reaches a concrete
+// value. Nil pointers, interfaces, maps, slices, channels and funcs become nil.
+func indirect(value any) any {
+	rv := reflect.ValueOf(value)
+	for {
+		switch rv.Kind() {
+		case reflect.Invalid:
+			return nil
+		case reflect.Pointer, reflect.Interface:
+			if rv.IsNil() {
+				return nil
+			}
+			rv = rv.Elem()
+			continue
+		case reflect.Map, reflect.Slice, reflect.Chan, reflect.Func:
+			if rv.IsNil() {
+				return nil
+			}
+		}
+		return rv.Interface()
+	}
+}
+
 // formatString handles string formatting with multiline support
 func formatString(s string, _indent string) string {
 	s = strings.TrimSpace(s)
@@ -289,7 +632,6 @@ func formatString(s string, _indent string) string {
 	return s
 }
 
-
 // LLML is a backwards compatibility alias for Sprintf
 // Deprecated: Use Sprintf instead
 func LLML(data interface{}, opts ...Options) string {
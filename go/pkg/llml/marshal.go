@@ -0,0 +1,119 @@
+package llml
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Marshal converts data structures to XML-like markup like Sprintf, but
+// reports values it cannot faithfully render instead of falling back to %v.
+// Unsupported types (channels, funcs, maps with unrenderable keys), invalid tag
+// names and, when Options.Cycles is CycleAsError or Options.NaN is
+// NaNAsError, reference cycles and non-finite floats are reported as a
+// *MarshalError naming the offending data path.
+func Marshal(data any, opts ...Options) ([]byte, error) {
+	s, err := MarshalString(data, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(s), nil
+}
+
+// MarshalString is like Marshal but returns the markup as a string
+func MarshalString(data any, opts ...Options) (string, error) {
+	options := Options{}
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+
+	result, err := encode(data, options)
+	if err != nil {
+		return "", err
+	}
+	return result, nil
+}
+
+// MarshalError describes a value that Marshal could not render
+type MarshalError struct {
+	// Path is the data path of the offending value, e.g. "$.config.callback"
+	Path string
+	// Err is the underlying cause: an *UnsupportedTypeError,
+	// *UnsupportedValueError, *InvalidTagNameError or *CycleError
+	Err error
+}
+
+func (e *MarshalError) Error() string {
+	return fmt.Sprintf("llml: cannot marshal %s: %v", e.Path, e.Err)
+}
+
+func (e *MarshalError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedTypeError is returned by Marshal for values with no meaningful text representation
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return "unsupported type " + e.Type.String()
+}
+
+// UnsupportedValueError is returned by Marshal for values the configured
+// options refuse to render, such as NaN under NaNAsError
+type UnsupportedValueError struct {
+	Value any
+}
+
+func (e *UnsupportedValueError) Error() string {
+	return fmt.Sprintf("unsupported value %v", e.Value)
+}
+
+// InvalidTagNameError is returned by Marshal for keys that do not form a well-formed tag
+type InvalidTagNameError struct {
+	Name string
+}
+
+func (e *InvalidTagNameError) Error() string {
+	return fmt.Sprintf("invalid tag name %q", e.Name)
+}
+
+// checkSupported records an *UnsupportedTypeError for values that can only be
+// rendered via %v
+func checkSupported(rv reflect.Value, path string, opts Options) {
+	switch rv.Kind() {
+	case reflect.Chan, reflect.Func, reflect.UnsafePointer, reflect.Map:
+		opts.state.fail(&MarshalError{Path: path, Err: &UnsupportedTypeError{Type: rv.Type()}})
+	}
+}
+
+// checkTagName records an *InvalidTagNameError for names that would not
+// produce a well-formed tag
+func checkTagName(name, path string, opts Options) {
+	if !isValidTagName(name) {
+		opts.state.fail(&MarshalError{Path: path, Err: &InvalidTagNameError{Name: name}})
+	}
+}
+
+// isValidTagName reports whether name can be used as a tag: it must be
+// non-empty, start with a letter, digit or underscore (see isTagStartRune)
+// and be free of whitespace, control characters and markup delimiters.
+func isValidTagName(name string) bool {
+	if first, _ := utf8.DecodeRuneInString(name); name == "" || !isTagStartRune(first) {
+		return false
+	}
+	for _, r := range name {
+		if !isTagRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isTagRune reports whether r may appear in a tag name
+func isTagRune(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsControl(r) && !strings.ContainsRune(`<>&"'/=`, r)
+}
@@ -0,0 +1,193 @@
+package llml
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LLMLMarshaler is implemented by types that render their own content.
+// MarshalLLML writes the content of the element holding the value through enc;
+// the element tag itself is written by the caller. As with encoding/json,
+// methods with pointer receivers are only used when the value is reached
+// through a pointer.
+type LLMLMarshaler interface {
+	MarshalLLML(enc *Encoder) error
+}
+
+// LLMLValuer is implemented by types that should be rendered as another value,
+// e.g. a Money type rendered as the string "$12.50"
+type LLMLValuer interface {
+	LLMLValue() (any, error)
+}
+
+// Encoder writes element content on behalf of an LLMLMarshaler. It renders
+// values with the same options, indentation and cycle tracking as the
+// surrounding document.
+type Encoder struct {
+	opts  Options
+	parts []string
+}
+
+// Encode renders v as content of the current element, as if v had been found
+// in place of the marshaler. Maps and structs contribute their elements,
+// slices their numbered items and primitives their text.
+func (e *Encoder) Encode(v any) error {
+	return e.capture(func() string {
+		return formatValue(v, e.opts)
+	})
+}
+
+// EncodeElement renders v as a child element named name
+func (e *Encoder) EncodeElement(name string, v any) error {
+	return e.capture(func() string {
+		return formatKeyValue(name, v, e.opts)
+	})
+}
+
+// capture appends the output of render and returns the first error it recorded
+func (e *Encoder) capture(render func() string) error {
+	state := e.opts.state
+	before := len(state.errs)
+	if out := render(); out != "" {
+		e.parts = append(e.parts, out)
+	}
+	if len(state.errs) > before {
+		return state.errs[before]
+	}
+	return nil
+}
+
+// marshalerValue wraps an LLMLMarshaler found by resolve so that it is
+// rendered in place of a map or struct
+type marshalerValue struct {
+	m LLMLMarshaler
+}
+
+var marshalerValueType = reflect.TypeOf(marshalerValue{})
+
+// isMarshalerValue reports whether v is an LLMLMarshaler wrapped by resolve
+func isMarshalerValue(v reflect.Value) bool {
+	return v.IsValid() && v.Type() == marshalerValueType
+}
+
+// formatMarshaler renders the content written by m
+func formatMarshaler(m LLMLMarshaler, opts Options) string {
+	enc := &Encoder{opts: opts}
+	if err := m.MarshalLLML(enc); err != nil {
+		// Errors returned by enc have already been recorded
+		var marshalErr *MarshalError
+		if !errors.As(err, &marshalErr) {
+			opts.state.fail(&MarshalError{Path: opts.path, Err: err})
+		}
+	}
+	return strings.Join(enc.parts, "\n")
+}
+
+// resolve dereferences value like indirect, applying the registry and any
+// LLMLMarshaler, LLMLValuer and text method (see Options.TextMethods)
+// implementations found along the way. Values matched by the registry become
+// formatted text, with secrets matched by Options.Redact and personal data
+// found by Options.Detectors in strings replaced, and marshalers are wrapped
+// in a marshalerValue for formatMap to render.
+func resolve(value any, path string, opts Options) any {
+	for {
+		rv := reflect.ValueOf(value)
+		switch rv.Kind() {
+		case reflect.Invalid:
+			return nil
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			// Nil maps and slices render as nil even if they implement a method
+			if rv.IsNil() {
+				return nil
+			}
+		}
+
+		switch value.(type) {
+		case formatted, omitted:
+			return value
+		}
+
+		if u, ok := value.(untrusted); ok {
+			value = u.value
+			continue
+		}
+
+		if f, ok := value.(fenced); ok {
+			value = f.value
+			continue
+		}
+
+		if isNonFinite(value) {
+			switch opts.NaN {
+			case NaNOmit:
+				return omitted{}
+			case NaNAsError:
+				opts.state.fail(&MarshalError{Path: path, Err: &UnsupportedValueError{Value: value}})
+			}
+		}
+
+		if text, ok := registryFor(opts).format(value, opts); ok {
+			return leafText(text, value, path, opts)
+		}
+
+		if m, ok := value.(LLMLMarshaler); ok {
+			return marshalerValue{m: m}
+		}
+
+		if v, ok := value.(LLMLValuer); ok {
+			replaced, err := v.LLMLValue()
+			if err != nil {
+				opts.state.fail(&MarshalError{Path: path, Err: err})
+				return leafValue(indirect(value), path, opts)
+			}
+			// A valuer returning its own type is rendered as is
+			if reflect.TypeOf(replaced) == rv.Type() {
+				return leafValue(indirect(replaced), path, opts)
+			}
+			value = replaced
+			continue
+		}
+
+		if text, ok := textValue(value, path, opts); ok {
+			value = text
+			continue
+		}
+
+		if rv.Kind() != reflect.Pointer {
+			return leafValue(indirect(value), path, opts)
+		}
+		value = rv.Elem().Interface()
+	}
+}
+
+// leafValue renders value, resolved at path without a registry rule, as
+// formatted text unless it is nil, an object or a list
+func leafValue(value any, path string, opts Options) any {
+	rv := reflect.ValueOf(value)
+	if value == nil || isObjectValue(rv) || isListValue(rv) {
+		return value
+	}
+	if _, isBytes := byteContents(value); isBytes {
+		// Registries without the bytes rule still render bytes, not a %v dump
+		return leafText(formatBytes(value, opts), value, path, opts)
+	}
+	checkSupported(rv, path, opts)
+	return leafText(fmt.Sprintf("%v", value), value, path, opts)
+}
+
+// leafText finishes the text rendered for the leaf value at path, however it
+// was rendered. Secrets are redacted first so they never reach
+// Report.Pseudonyms, then personal data is masked in textual leaves. Leaves
+// omitted under RedactOmit are returned as omitted{}.
+func leafText(text string, value any, path string, opts Options) any {
+	text, ok := redactText(text, path, opts)
+	if !ok {
+		return omitted{}
+	}
+	if isTextual(value, opts) {
+		text = maskText(text, opts)
+	}
+	return formatted(text)
+}
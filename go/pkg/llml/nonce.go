@@ -0,0 +1,79 @@
+package llml
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+)
+
+// Fenced marks v as a section whose element tag carries a nonce that is
+// unique to the render, e.g. <user_input-7f3a9c0b>. Text inside the section
+// cannot close it without guessing the nonce, which is returned in
+// Report.Nonce so the system prompt can refer to the section by name. Combine
+// it with Untrusted to also neutralise the tags the text does know. A Fenced
+// value passed directly to Sprintf has no element of its own and renders as v.
+func Fenced(v any) any {
+	return fenced{value: v}
+}
+
+// fenced wraps a value passed to Fenced
+type fenced struct {
+	value any
+}
+
+// nonceSize is the number of random bytes in a nonce
+const nonceSize = 4
+
+// fenceTag returns tag suffixed with the render's nonce if the unresolved
+// value raw is wrapped by Fenced, directly or inside Untrusted
+func fenceTag(tag string, raw any, path string, opts Options) string {
+	for {
+		switch v := raw.(type) {
+		case fenced:
+			nonce := opts.state.fenceNonce(path, opts)
+			if nonce == "" {
+				// No randomness was available; a guessable fence is no fence
+				return tag
+			}
+			return tag + "-" + nonce
+		case untrusted:
+			raw = v.value
+		default:
+			return tag
+		}
+	}
+}
+
+// fenceNonce returns the nonce shared by every Fenced section of the render,
+// drawing it from Options.Rand the first time it is needed. If Options.Rand
+// fails, the error is recorded and crypto/rand is used instead; if that fails
+// too, the nonce is empty.
+func (s *encodeState) fenceNonce(path string, opts Options) string {
+	if s.nonce != "" || s.nonceFailed {
+		return s.nonce
+	}
+
+	b := make([]byte, nonceSize)
+	if opts.Rand != nil {
+		if _, err := io.ReadFull(opts.Rand, b); err != nil {
+			s.fail(&MarshalError{Path: path, Err: err})
+		} else {
+			return s.setNonce(b, opts)
+		}
+	}
+	if _, err := io.ReadFull(rand.Reader, b); err != nil {
+		s.fail(&MarshalError{Path: path, Err: err})
+		s.nonceFailed = true
+		return ""
+	}
+	return s.setNonce(b, opts)
+}
+
+// setNonce records the nonce made of b and reports it in Options.Report
+func (s *encodeState) setNonce(b []byte, opts Options) string {
+	s.nonce = hex.EncodeToString(b)
+	if opts.Report != nil {
+		opts.Report.Nonce = s.nonce
+	}
+	return s.nonce
+}
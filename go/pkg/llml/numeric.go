@@ -0,0 +1,146 @@
+package llml
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// FloatFormat controls the notation and precision of floating point values,
+// including *big.Float and *big.Rat. The zero value renders the shortest
+// representation that round-trips, as strconv's 'g' format does.
+type FloatFormat struct {
+	// Fixed renders floats with exactly Precision digits after the decimal
+	// point, e.g. 0.1+0.2 as "0.30" with Precision 2
+	Fixed     bool
+	Precision int
+
+	// ExponentAbove and ExponentBelow, if set, are the magnitudes at and
+	// below which floats switch to exponent notation; values in between use
+	// decimal notation. For example ExponentAbove: 1e21 renders 1e20 as
+	// "100000000000000000000" and 1e21 as "1e+21".
+	ExponentAbove float64
+	ExponentBelow float64
+}
+
+// verb returns the strconv format for a value of magnitude abs
+func (f FloatFormat) verb(abs float64) byte {
+	if f.ExponentAbove == 0 && f.ExponentBelow == 0 {
+		if f.Fixed {
+			return 'f'
+		}
+		return 'g'
+	}
+	if (f.ExponentAbove > 0 && abs >= f.ExponentAbove) || (abs != 0 && abs < f.ExponentBelow) {
+		return 'e'
+	}
+	return 'f'
+}
+
+// precision returns the strconv precision: Precision when Fixed, otherwise
+// the shortest representation
+func (f FloatFormat) precision() int {
+	if f.Fixed {
+		return f.Precision
+	}
+	return -1
+}
+
+// NaNStyle controls how NaN and infinite floats are handled
+type NaNStyle int
+
+const (
+	// NaNAsText renders "NaN", "+Inf" and "-Inf" (the default)
+	NaNAsText NaNStyle = iota
+	// NaNOmit omits elements whose value is NaN or infinite
+	NaNOmit
+	// NaNAsError renders the text like NaNAsText and reports an
+	// *UnsupportedValueError from Marshal
+	NaNAsError
+)
+
+// isNonFinite reports whether value is a NaN or infinite float or *big.Float
+func isNonFinite(value any) bool {
+	switch v := value.(type) {
+	case float32:
+		return math.IsNaN(float64(v)) || math.IsInf(float64(v), 0)
+	case float64:
+		return math.IsNaN(v) || math.IsInf(v, 0)
+	case *big.Float:
+		return v.IsInf()
+	}
+	return false
+}
+
+func isFloat(value any) bool {
+	switch value.(type) {
+	case float32, float64:
+		return true
+	}
+	return false
+}
+
+func formatFloat(value any, opts Options) string {
+	v, bitSize := 0.0, 64
+	if f, ok := value.(float32); ok {
+		v, bitSize = float64(f), 32
+	} else {
+		v = value.(float64)
+	}
+
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'g', -1, bitSize)
+	}
+	return strconv.FormatFloat(v, opts.Floats.verb(math.Abs(v)), opts.Floats.precision(), bitSize)
+}
+
+func isBigInt(value any) bool {
+	_, ok := value.(*big.Int)
+	return ok
+}
+
+func formatBigInt(value any, _ Options) string {
+	return value.(*big.Int).String()
+}
+
+func isBigFloat(value any) bool {
+	_, ok := value.(*big.Float)
+	return ok
+}
+
+// formatBigFloat renders x with all of its precision, using Options.Floats
+// for notation
+func formatBigFloat(value any, opts Options) string {
+	x := value.(*big.Float)
+	if x.IsInf() {
+		return x.String()
+	}
+	abs, _ := new(big.Float).Abs(x).Float64()
+	return x.Text(opts.Floats.verb(abs), opts.Floats.precision())
+}
+
+func isBigRat(value any) bool {
+	_, ok := value.(*big.Rat)
+	return ok
+}
+
+// formatBigRat renders x as an exact fraction such as "1/3", or as a decimal
+// with Options.Floats.Precision digits when Fixed is set
+func formatBigRat(value any, opts Options) string {
+	x := value.(*big.Rat)
+	if opts.Floats.Fixed {
+		return x.FloatString(opts.Floats.Precision)
+	}
+	return x.RatString()
+}
+
+func isJSONNumber(value any) bool {
+	_, ok := value.(json.Number)
+	return ok
+}
+
+// formatJSONNumber renders a json.Number verbatim, so no precision is lost
+func formatJSONNumber(value any, _ Options) string {
+	return value.(json.Number).String()
+}
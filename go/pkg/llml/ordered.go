@@ -0,0 +1,62 @@
+package llml
+
+import "fmt"
+
+// Ordered is a list of key-value pairs rendered in insertion order. Use it in
+// place of a map wherever the reading order of elements matters, at any
+// nesting level:
+//
+//	llml.Sprintf(llml.KV(
+//		"task", "Summarize the ticket",
+//		"context", map[string]any{"customer": "Acme"},
+//	))
+type Ordered []Pair
+
+// Pair is a single element of an Ordered
+type Pair struct {
+	Key   string
+	Value any
+}
+
+// KV builds an Ordered from alternating keys and values. It panics if a key
+// is not a string or the last key has no value.
+func KV(keysAndValues ...any) Ordered {
+	if len(keysAndValues)%2 != 0 {
+		panic(fmt.Sprintf("llml: KV called with odd number of arguments (%d)", len(keysAndValues)))
+	}
+
+	o := make(Ordered, 0, len(keysAndValues)/2)
+	for i := 0; i < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			panic(fmt.Sprintf("llml: KV key at position %d is %T, not string", i, keysAndValues[i]))
+		}
+		o = append(o, Pair{Key: key, Value: keysAndValues[i+1]})
+	}
+	return o
+}
+
+// Set replaces the value of key, keeping its position, or appends key if it
+// is not present
+func (o Ordered) Set(key string, value any) Ordered {
+	for i := range o {
+		if o[i].Key == key {
+			o[i].Value = value
+			return o
+		}
+	}
+	return append(o, Pair{Key: key, Value: value})
+}
+
+// MarshalLLML renders the pairs as elements in insertion order. Rendering
+// continues past values that fail so that Sprintf output stays complete;
+// the first error is returned.
+func (o Ordered) MarshalLLML(enc *Encoder) error {
+	var first error
+	for _, p := range o {
+		if err := enc.EncodeElement(p.Key, p.Value); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
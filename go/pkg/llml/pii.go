@@ -0,0 +1,220 @@
+package llml
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Detector finds personal data, such as email addresses, in the text of
+// string leaves and of bytes rendered as text. Each finding is replaced by a
+// placeholder named after Kind, e.g. [EMAIL_1], and listed in
+// Report.Pseudonyms.
+type Detector interface {
+	// Kind names what the detector finds, e.g. "EMAIL"
+	Kind() string
+	// Detect returns the start and end byte offsets of each finding in text,
+	// in order and without overlaps, like regexp.FindAllStringIndex
+	Detect(text string) [][]int
+}
+
+// regexDetector is a Detector backed by a regular expression, with an
+// optional check that rejects false positives given the match at loc in text
+type regexDetector struct {
+	kind    string
+	pattern *regexp.Regexp
+	valid   func(text string, loc []int) bool
+}
+
+func (d regexDetector) Kind() string {
+	return d.kind
+}
+
+func (d regexDetector) Detect(text string) [][]int {
+	matches := d.pattern.FindAllStringIndex(text, -1)
+	if d.valid == nil {
+		return matches
+	}
+	found := matches[:0]
+	for _, m := range matches {
+		if d.valid(text, m) {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// RegexDetector returns a Detector that finds matches of pattern, reported as kind
+func RegexDetector(kind string, pattern *regexp.Regexp) Detector {
+	return regexDetector{kind: kind, pattern: pattern}
+}
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(?:\.[A-Za-z0-9-]+)*\.[A-Za-z]{2,}`)
+	phonePattern = regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{2,4}\)[ .-]?|\b\d{2,4}[ .-]?)\d{3,4}[ .-]?\d{3,4}\b`)
+	cardPattern  = regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`)
+	ipPattern    = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`)
+)
+
+// EmailDetector returns a Detector for email addresses, reported as EMAIL
+func EmailDetector() Detector {
+	return regexDetector{kind: "EMAIL", pattern: emailPattern}
+}
+
+// PhoneDetector returns a Detector for phone numbers of 8 to 12 digits,
+// optionally with a country code and separators, e.g. "+1 (555) 123-4567",
+// reported as PHONE
+func PhoneDetector() Detector {
+	return regexDetector{kind: "PHONE", pattern: phonePattern, valid: isolatedDigits}
+}
+
+// CardDetector returns a Detector for payment card numbers of 13 to 19
+// digits that pass the Luhn check, reported as CARD
+func CardDetector() Detector {
+	return regexDetector{kind: "CARD", pattern: cardPattern, valid: func(text string, loc []int) bool {
+		return luhnValid(text[loc[0]:loc[1]])
+	}}
+}
+
+// IPDetector returns a Detector for IPv4 and IPv6 addresses, reported as IP
+func IPDetector() Detector {
+	return regexDetector{kind: "IP", pattern: ipPattern, valid: func(text string, loc []int) bool {
+		match := text[loc[0]:loc[1]]
+		// Without a digit, "std::" would pass as the IPv6 address d::
+		if !strings.ContainsAny(match, "0123456789") {
+			return false
+		}
+		_, err := netip.ParseAddr(match)
+		return err == nil
+	}}
+}
+
+// DefaultDetectors returns the built-in detectors for email addresses, card
+// numbers, IP addresses and phone numbers. Card numbers are detected before
+// phone numbers, which would otherwise match their leading digits.
+func DefaultDetectors() []Detector {
+	return []Detector{EmailDetector(), CardDetector(), IPDetector(), PhoneDetector()}
+}
+
+// isolatedDigits reports whether the match at loc in text is not part of a
+// longer group of digits, such as a card number whose first twelve digits
+// would pass as a phone number
+func isolatedDigits(text string, loc []int) bool {
+	isDigit := func(i int) bool {
+		return i >= 0 && i < len(text) && text[i] >= '0' && text[i] <= '9'
+	}
+	isSeparator := func(i int) bool {
+		return i >= 0 && i < len(text) && strings.IndexByte(" .-", text[i]) >= 0
+	}
+	start, end := loc[0], loc[1]
+	if isSeparator(start-1) && isDigit(start-2) {
+		return false
+	}
+	return !(isSeparator(end) && isDigit(end+1))
+}
+
+// luhnValid reports whether the digits of number pass the Luhn checksum
+func luhnValid(number string) bool {
+	sum, double := 0, false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// pseudonyms assigns placeholders to personal data consistently within a
+// render, so the same email address always becomes the same placeholder
+type pseudonyms struct {
+	placeholders map[string]string
+	counts       map[string]int
+}
+
+// placeholder returns the placeholder for value found by a detector of kind,
+// recording new ones in Options.Report
+func (p *pseudonyms) placeholder(kind, value string, opts Options) string {
+	key := kind + "\x00" + value
+	if name, ok := p.placeholders[key]; ok {
+		return name
+	}
+	if p.placeholders == nil {
+		p.placeholders = map[string]string{}
+		p.counts = map[string]int{}
+	}
+	p.counts[kind]++
+	name := fmt.Sprintf("[%s_%d]", kind, p.counts[kind])
+	p.placeholders[key] = name
+
+	if opts.Report != nil {
+		if opts.Report.Pseudonyms == nil {
+			opts.Report.Pseudonyms = map[string]string{}
+		}
+		opts.Report.Pseudonyms[name] = value
+	}
+	return name
+}
+
+// isTextual reports whether the leaf value renders as text that may hold
+// personal data: strings, including named string types, and bytes under
+// BytesAsText. Numbers and times are left alone.
+func isTextual(value any, opts Options) bool {
+	if reflect.ValueOf(value).Kind() == reflect.String {
+		return true
+	}
+	_, isBytes := byteContents(value)
+	return isBytes && opts.Bytes == BytesAsText
+}
+
+// maskText replaces the personal data found by Options.Detectors in text
+func maskText(text string, opts Options) string {
+	for _, d := range opts.Detectors {
+		found := d.Detect(text)
+		if len(found) == 0 {
+			continue
+		}
+		var b strings.Builder
+		last := 0
+		for _, m := range found {
+			b.WriteString(text[last:m[0]])
+			b.WriteString(opts.state.pseudonyms.placeholder(d.Kind(), text[m[0]:m[1]], opts))
+			last = m[1]
+		}
+		b.WriteString(text[last:])
+		text = b.String()
+	}
+	return text
+}
+
+// Unmask replaces the placeholders recorded in r.Pseudonyms with the
+// personal data they stand for, e.g. to restore a model response that
+// quotes them
+func (r *Report) Unmask(text string) string {
+	if r == nil || len(r.Pseudonyms) == 0 {
+		return text
+	}
+	names := make([]string, 0, len(r.Pseudonyms))
+	for name := range r.Pseudonyms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		pairs = append(pairs, name, r.Pseudonyms[name])
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
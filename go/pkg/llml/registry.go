@@ -0,0 +1,157 @@
+package llml
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+// Predicate reports whether a Formatter applies to a value
+type Predicate func(value any) bool
+
+// Formatter renders a value as element text. opts carries the options in
+// effect where the value was found (indentation, prefix, etc.).
+type Formatter func(value any, opts Options) string
+
+// rule pairs a predicate with the formatter it selects
+type rule struct {
+	predicate Predicate
+	formatter Formatter
+}
+
+// Registry is an ordered list of predicate/formatter pairs. Values are
+// rendered by the formatter of the first matching predicate; values that
+// match none are traversed as maps, structs and slices, or fall back to %v.
+type Registry struct {
+	rules []rule
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// DefaultRegistry returns a registry with the built-in formatters for
+// strings, booleans, integers, floats, big numbers, json.Number, byte
+// slices, times and durations
+func DefaultRegistry() *Registry {
+	return &Registry{rules: []rule{
+		{isTime, formatTime},
+		{isDuration, formatDuration},
+		{isJSONNumber, formatJSONNumber},
+		{isBytes, formatBytes},
+		{isString, formatStringValue},
+		{isBool, formatBool},
+		{isInt, formatInt},
+		{isUint, formatUint},
+		{isFloat, formatFloat},
+		{isBigInt, formatBigInt},
+		{isBigFloat, formatBigFloat},
+		{isBigRat, formatBigRat},
+	}}
+}
+
+// Register adds a formatter for values matching predicate. It takes precedence
+// over the rules already registered, so defaults can be overridden:
+//
+//	registry := llml.DefaultRegistry().Register(isEmail, formatEmail)
+func (r *Registry) Register(predicate Predicate, formatter Formatter) *Registry {
+	r.rules = append([]rule{{predicate, formatter}}, r.rules...)
+	return r
+}
+
+// format renders value with the first matching formatter
+func (r *Registry) format(value any, opts Options) (string, bool) {
+	for _, rule := range r.rules {
+		if rule.predicate(value) {
+			return rule.formatter(value, opts), true
+		}
+	}
+	return "", false
+}
+
+// defaultRegistry is used when Options.Registry is nil
+var defaultRegistry = DefaultRegistry()
+
+// registryFor returns the registry configured in opts
+func registryFor(opts Options) *Registry {
+	if opts.Registry != nil {
+		return opts.Registry
+	}
+	return defaultRegistry
+}
+
+// formatted is text already rendered by the registry
+type formatted string
+
+// isString matches strings, including named string types such as
+// type Name string, unless they render through a method of their own
+func isString(value any) bool {
+	switch value.(type) {
+	case string:
+		return true
+	case LLMLMarshaler, LLMLValuer, encoding.TextMarshaler, error, fmt.Stringer:
+		return false
+	}
+	return reflect.ValueOf(value).Kind() == reflect.String
+}
+
+func formatStringValue(value any, opts Options) string {
+	return formatString(reflect.ValueOf(value).String(), opts.Indent)
+}
+
+func isBool(value any) bool {
+	_, ok := value.(bool)
+	return ok
+}
+
+func formatBool(value any, _ Options) string {
+	return strconv.FormatBool(value.(bool))
+}
+
+func isInt(value any) bool {
+	switch value.(type) {
+	case int, int8, int16, int32, int64:
+		return true
+	}
+	return false
+}
+
+func formatInt(value any, _ Options) string {
+	switch v := value.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case int8:
+		return strconv.FormatInt(int64(v), 10)
+	case int16:
+		return strconv.FormatInt(int64(v), 10)
+	case int32:
+		return strconv.FormatInt(int64(v), 10)
+	default:
+		return strconv.FormatInt(v.(int64), 10)
+	}
+}
+
+func isUint(value any) bool {
+	switch value.(type) {
+	case uint, uint8, uint16, uint32, uint64:
+		return true
+	}
+	return false
+}
+
+func formatUint(value any, _ Options) string {
+	switch v := value.(type) {
+	case uint:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10)
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10)
+	default:
+		return strconv.FormatUint(v.(uint64), 10)
+	}
+}
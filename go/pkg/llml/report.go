@@ -0,0 +1,23 @@
+package llml
+
+// Report describes what a render changed in the data to keep the output
+// safe. Pass a pointer in Options.Report to receive it; it is reset at the
+// start of every render, except renders started by a Formatter with the
+// options it was given, which add to the report of the render they are in.
+type Report struct {
+	// Neutralized lists the tag-like text neutralised in Untrusted values
+	Neutralized []Neutralization
+	// Nonce is the suffix of the tags of Fenced sections, e.g. "7f3a9c0b",
+	// or empty if no Fenced section was rendered
+	Nonce string
+	// Pseudonyms maps the placeholders of masked personal data, e.g.
+	// "[EMAIL_1]", to the text they replaced; see Unmask
+	Pseudonyms map[string]string
+}
+
+// reset clears r for a new render; a nil report is left alone
+func (r *Report) reset() {
+	if r != nil {
+		*r = Report{}
+	}
+}
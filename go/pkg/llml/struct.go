@@ -0,0 +1,342 @@
+package llml
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+)
+
+// structField describes how a single struct field is rendered
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+	tagged    bool
+	// parents are the elements the field is nested in, from an xml tag
+	// such as `xml:"address>city"`
+	parents []string
+}
+
+// path returns the parents and name of f joined as in an xml tag, which
+// identifies the field when resolving name conflicts
+func (f structField) path() string {
+	return strings.Join(append(append([]string(nil), f.parents...), f.name), ">")
+}
+
+var xmlNameType = reflect.TypeOf(xml.Name{})
+
+// isStructValue reports whether v is a struct that should be rendered field by field.
+// Structs without exported or embedded fields (e.g. time.Time) are opaque and
+// left to the primitive formatter.
+func isStructValue(v reflect.Value) bool {
+	if v.Kind() != reflect.Struct {
+		return false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if sf := t.Field(i); sf.IsExported() || sf.Anonymous {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultTagKeys is the struct tag precedence used when Options.TagKeys is empty
+var defaultTagKeys = []string{"llml"}
+
+// structFields returns the renderable fields of t in declaration order.
+// Each field is named by the first tag in tagKeys present on it (e.g.
+// `llml:"name,omitempty"`), falling back to the Go field name. Unexported
+// fields and fields whose deciding tag is "-" are skipped.
+//
+// Fields of embedded structs without a tag name, and of struct fields tagged
+// `llml:",inline"`, are promoted into t. As with encoding/json, a shallower
+// field hides promoted fields of the same name, and conflicting fields at the
+// same depth are dropped unless exactly one of them is tagged.
+func structFields(t reflect.Type, tagKeys []string) []structField {
+	if len(tagKeys) == 0 {
+		tagKeys = defaultTagKeys
+	}
+
+	fields := collectFields(t, tagKeys, nil, map[reflect.Type]bool{})
+
+	// Resolve name conflicts between promoted fields
+	byName := make(map[string][]int, len(fields))
+	for i, f := range fields {
+		byName[f.path()] = append(byName[f.path()], i)
+	}
+
+	var result []structField
+	for i, f := range fields {
+		if dominantField(fields, byName[f.path()]) == i {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// collectFields walks t depth-first, flattening embedded and inline structs.
+// visiting guards against infinitely recursive embedded types.
+func collectFields(t reflect.Type, tagKeys []string, index []int, visiting map[reflect.Type]bool) []structField {
+	if visiting[t] {
+		return nil
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+
+		tag, key := lookupTag(sf.Tag, tagKeys)
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+
+		var parents []string
+		if key == "xml" {
+			var skip bool
+			parents, name, skip = parseXMLName(sf, name, opts)
+			if skip {
+				continue
+			}
+		}
+
+		fieldIndex := make([]int, len(index)+1)
+		copy(fieldIndex, index)
+		fieldIndex[len(index)] = i
+
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+
+		// Exported fields of unexported embedded structs are still promoted
+		inline := ft.Kind() == reflect.Struct &&
+			((sf.Anonymous && name == "") || (opts.contains("inline") && (sf.IsExported() || sf.Anonymous)))
+		if inline {
+			fields = append(fields, collectFields(ft, tagKeys, fieldIndex, visiting)...)
+			continue
+		}
+
+		if !sf.IsExported() {
+			continue
+		}
+		tagged := name != ""
+		if !tagged {
+			name = sf.Name
+		}
+
+		fields = append(fields, structField{
+			name:      name,
+			index:     fieldIndex,
+			omitEmpty: opts.contains("omitempty"),
+			tagged:    tagged,
+			parents:   parents,
+		})
+	}
+	return fields
+}
+
+// dominantField returns the position in fields of the field that wins among
+// candidates sharing a name, or -1 if the conflict cannot be resolved
+func dominantField(fields []structField, candidates []int) int {
+	if len(candidates) == 1 {
+		return candidates[0]
+	}
+
+	depth := len(fields[candidates[0]].index)
+	for _, c := range candidates[1:] {
+		if d := len(fields[c].index); d < depth {
+			depth = d
+		}
+	}
+
+	winner := -1
+	untagged := -1
+	shallowest := 0
+	for _, c := range candidates {
+		if len(fields[c].index) != depth {
+			continue
+		}
+		shallowest++
+		if fields[c].tagged {
+			if winner >= 0 {
+				return -1
+			}
+			winner = c
+		} else {
+			untagged = c
+		}
+	}
+
+	if winner >= 0 {
+		return winner
+	}
+	if shallowest == 1 {
+		return untagged
+	}
+	return -1
+}
+
+// structEntries returns the key-value pairs of a struct in field declaration order
+func structEntries(v reflect.Value, tagKeys []string) []entry {
+	fields := structFields(v.Type(), tagKeys)
+	entries := make([]entry, 0, len(fields))
+	for _, f := range fields {
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok {
+			// Promoted through a nil embedded pointer
+			continue
+		}
+		if f.omitEmpty && isEmptyValue(fv) {
+			continue
+		}
+		entries = appendField(entries, f.parents, f.name, fv.Interface())
+	}
+	return entries
+}
+
+// fieldGroup holds the fields nested under a parent element by xml tags
+// such as `xml:"address>city"`
+type fieldGroup struct {
+	fields Ordered
+}
+
+// MarshalLLML renders the grouped fields in declaration order
+func (g *fieldGroup) MarshalLLML(enc *Encoder) error {
+	return g.fields.MarshalLLML(enc)
+}
+
+// add appends the field name, nested in parents, to g
+func (g *fieldGroup) add(parents []string, name string, value any) {
+	if len(parents) == 0 {
+		g.fields = append(g.fields, Pair{Key: name, Value: value})
+		return
+	}
+	var sub *fieldGroup
+	if n := len(g.fields); n > 0 && g.fields[n-1].Key == parents[0] {
+		sub, _ = g.fields[n-1].Value.(*fieldGroup)
+	}
+	if sub == nil {
+		sub = &fieldGroup{}
+		g.fields = append(g.fields, Pair{Key: parents[0], Value: sub})
+	}
+	sub.add(parents[1:], name, value)
+}
+
+// appendField appends the field name, nested in parents, to entries. As with
+// encoding/xml, adjacent fields sharing a parent are grouped in one element.
+func appendField(entries []entry, parents []string, name string, value any) []entry {
+	if len(parents) == 0 {
+		return append(entries, entry{key: name, value: value})
+	}
+	var group *fieldGroup
+	if n := len(entries); n > 0 && entries[n-1].key == parents[0] {
+		group, _ = entries[n-1].value.(*fieldGroup)
+	}
+	if group == nil {
+		group = &fieldGroup{}
+		entries = append(entries, entry{key: parents[0], value: group})
+	}
+	group.add(parents[1:], name, value)
+	return entries
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex but reports false instead of
+// panicking when the path crosses a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// lookupTag returns the value and key of the first tag key present in tag,
+// or "" if none is
+func lookupTag(tag reflect.StructTag, tagKeys []string) (string, string) {
+	for _, key := range tagKeys {
+		if value, ok := tag.Lookup(key); ok {
+			return value, key
+		}
+	}
+	return "", ""
+}
+
+// parseXMLName interprets the name and options of the xml tag of sf as
+// encoding/xml does, as far as they apply to elements:
+//   - "a>b" nests the field in an element a, returned as parents
+//   - ",attr" fields are rendered as elements like any other field
+//   - ",chardata", ",cdata" and ",innerxml" fields are named after the Go field
+//   - ",comment" fields and the XMLName field are skipped
+func parseXMLName(sf reflect.StructField, name string, opts tagOptions) (parents []string, leaf string, skip bool) {
+	if sf.Name == "XMLName" && sf.Type == xmlNameType {
+		return nil, "", true
+	}
+	switch {
+	case opts.contains("comment"):
+		return nil, "", true
+	case opts.contains("chardata"), opts.contains("cdata"), opts.contains("innerxml"):
+		return nil, "", false
+	}
+
+	var path []string
+	for _, part := range strings.Split(name, ">") {
+		if part = strings.TrimSpace(part); part != "" {
+			path = append(path, part)
+		}
+	}
+	if len(path) == 0 {
+		return nil, "", false
+	}
+	return path[:len(path)-1], path[len(path)-1], false
+}
+
+// tagOptions is the comma-separated list of options following a tag name
+type tagOptions string
+
+// parseTag splits a struct tag into its name and options
+func parseTag(tag string) (string, tagOptions) {
+	name, opts, _ := strings.Cut(tag, ",")
+	return name, tagOptions(opts)
+}
+
+// contains reports whether the option list includes the given option
+func (o tagOptions) contains(option string) bool {
+	s := string(o)
+	for s != "" {
+		var current string
+		current, s, _ = strings.Cut(s, ",")
+		if current == option {
+			return true
+		}
+	}
+	return false
+}
+
+// isEmptyValue reports whether v is the zero value for omitempty purposes,
+// using the same rules as encoding/json
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package llml
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// TagPolicy rewrites names that would not form well-formed tags, so that
+// arbitrary keys (e.g. from user-supplied JSON) always yield valid markup.
+// The zero value writes names verbatim, in which case Marshal reports
+// malformed names as an *InvalidTagNameError.
+type TagPolicy struct {
+	// Replacement replaces each run of characters not allowed in tag names
+	// (whitespace, control characters and <>&"'/=); runs at either end are
+	// trimmed. For example "-" turns "key  with spaces" into
+	// "key-with-spaces" and "<b>" into "b".
+	Replacement string
+
+	// DigitPrefix is prepended to names starting with a digit, e.g. "_"
+	// turns "2fa" into "_2fa" and list item "1" into "_1"
+	DigitPrefix string
+
+	// StartPrefix is prepended to names starting with any other character
+	// that cannot start a tag, i.e. anything but a letter, digit or
+	// underscore, e.g. "_" turns "-flag" into "_-flag". Empty names become
+	// StartPrefix. Defaults to "_" whenever Replacement or DigitPrefix is set.
+	StartPrefix string
+}
+
+// defaultStartPrefix is used when TagPolicy.StartPrefix is empty
+const defaultStartPrefix = "_"
+
+// sanitize applies the policy to name
+func (p TagPolicy) sanitize(name string) string {
+	if p == (TagPolicy{}) {
+		return name
+	}
+	startPrefix := p.StartPrefix
+	if startPrefix == "" {
+		startPrefix = defaultStartPrefix
+	}
+
+	if p.Replacement != "" {
+		name = replaceInvalidRuns(strings.TrimFunc(name, isNotTagRune), p.Replacement)
+	}
+	if name == "" {
+		return startPrefix
+	}
+
+	first, _ := utf8.DecodeRuneInString(name)
+	switch {
+	case unicode.IsDigit(first):
+		name = p.DigitPrefix + name
+	case !isTagStartRune(first):
+		name = startPrefix + name
+	}
+	return name
+}
+
+// isTagStartRune reports whether a tag name may start with r. Digits are
+// allowed too, as list items are numbered.
+func isTagStartRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+func isNotTagRune(r rune) bool {
+	return !isTagRune(r)
+}
+
+// replaceInvalidRuns replaces each run of runes not allowed in tag names with replacement
+func replaceInvalidRuns(name, replacement string) string {
+	var b strings.Builder
+	inRun := false
+	for _, r := range name {
+		if isTagRune(r) {
+			b.WriteRune(r)
+			inRun = false
+			continue
+		}
+		if !inRun {
+			b.WriteString(replacement)
+			inRun = true
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,52 @@
+package llml
+
+import (
+	"encoding"
+	"fmt"
+)
+
+// TextMethod identifies a method used to render a value as text
+type TextMethod int
+
+const (
+	// UseTextMarshaler renders values implementing encoding.TextMarshaler
+	UseTextMarshaler TextMethod = iota
+	// UseError renders values implementing the error interface
+	UseError
+	// UseStringer renders values implementing fmt.Stringer
+	UseStringer
+)
+
+// defaultTextMethods is the priority used when Options.TextMethods is nil
+var defaultTextMethods = []TextMethod{UseTextMarshaler, UseError, UseStringer}
+
+// textValue renders value through the first applicable text method
+func textValue(value any, path string, opts Options) (string, bool) {
+	methods := opts.TextMethods
+	if methods == nil {
+		methods = defaultTextMethods
+	}
+
+	for _, method := range methods {
+		switch method {
+		case UseTextMarshaler:
+			if m, ok := value.(encoding.TextMarshaler); ok {
+				text, err := m.MarshalText()
+				if err != nil {
+					opts.state.fail(&MarshalError{Path: path, Err: err})
+					continue
+				}
+				return string(text), true
+			}
+		case UseError:
+			if e, ok := value.(error); ok {
+				return e.Error(), true
+			}
+		case UseStringer:
+			if s, ok := value.(fmt.Stringer); ok {
+				return s.String(), true
+			}
+		}
+	}
+	return "", false
+}
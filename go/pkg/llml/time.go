@@ -0,0 +1,182 @@
+package llml
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TimeStyle controls how time.Time values are rendered
+type TimeStyle int
+
+const (
+	// TimeAsAbsolute renders times using Options.TimeLayout (the default)
+	TimeAsAbsolute TimeStyle = iota
+	// TimeAsRelative renders times relative to Options.Now, e.g. "3 hours ago"
+	TimeAsRelative
+	// TimeAsRelativeWithAbsolute renders relative times and records the
+	// absolute time in a datetime attribute, e.g.
+	// <deployed datetime="2024-03-05T13:30:00Z">3 hours ago</deployed>
+	TimeAsRelativeWithAbsolute
+)
+
+// DurationStyle controls how time.Duration values are rendered
+type DurationStyle int
+
+const (
+	// DurationAsString renders durations as time.Duration.String does, e.g. "1h30m0s" (the default)
+	DurationAsString DurationStyle = iota
+	// DurationAsSeconds renders durations as a number of seconds, e.g. "5400"
+	DurationAsSeconds
+	// DurationAsISO8601 renders durations in ISO 8601 form, e.g. "PT1H30M"
+	DurationAsISO8601
+)
+
+// defaultTimeLayout is the layout used when Options.TimeLayout is empty
+const defaultTimeLayout = time.RFC3339
+
+func isTime(value any) bool {
+	switch value.(type) {
+	case time.Time, *time.Time:
+		return true
+	}
+	return false
+}
+
+// formatTime renders a time under the configured TimeStyle
+func formatTime(value any, opts Options) string {
+	var t time.Time
+	switch v := value.(type) {
+	case *time.Time:
+		t = *v
+	default:
+		t = v.(time.Time)
+	}
+
+	if opts.Times != TimeAsAbsolute {
+		return formatRelativeTime(t, currentTime(opts))
+	}
+	return formatAbsoluteTime(t, opts)
+}
+
+// formatAbsoluteTime renders t in Options.TimeZone using Options.TimeLayout.
+// Formatting drops the monotonic clock reading that %v would print.
+func formatAbsoluteTime(t time.Time, opts Options) string {
+	if opts.TimeZone != nil {
+		t = t.In(opts.TimeZone)
+	}
+	layout := opts.TimeLayout
+	if layout == "" {
+		layout = defaultTimeLayout
+	}
+	return t.Format(layout)
+}
+
+// currentTime returns the reference time for relative times
+func currentTime(opts Options) time.Time {
+	if opts.Now != nil {
+		return opts.Now()
+	}
+	return time.Now()
+}
+
+// relativeUnits are the units used by formatRelativeTime, largest first
+var relativeUnits = []struct {
+	size time.Duration
+	name string
+}{
+	{365 * 24 * time.Hour, "year"},
+	{30 * 24 * time.Hour, "month"},
+	{24 * time.Hour, "day"},
+	{time.Hour, "hour"},
+	{time.Minute, "minute"},
+	{time.Second, "second"},
+}
+
+// formatRelativeTime describes t relative to now in the largest whole unit,
+// e.g. "3 hours ago", "in 2 days" or "just now"
+func formatRelativeTime(t, now time.Time) string {
+	d := now.Sub(t)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	for _, unit := range relativeUnits {
+		n := int64(d / unit.size)
+		if n < 1 {
+			continue
+		}
+		text := strconv.FormatInt(n, 10) + " " + unit.name
+		if n > 1 {
+			text += "s"
+		}
+		if future {
+			return "in " + text
+		}
+		return text + " ago"
+	}
+	return "just now"
+}
+
+// timeAttributes returns the datetime attribute recorded for relative times
+// under TimeAsRelativeWithAbsolute
+func timeAttributes(value any, opts Options) string {
+	if opts.Times != TimeAsRelativeWithAbsolute {
+		return ""
+	}
+	t, ok := indirect(value).(time.Time)
+	if !ok {
+		return ""
+	}
+	return formatAttr("datetime", formatAbsoluteTime(t, opts), opts)
+}
+
+func isDuration(value any) bool {
+	_, ok := value.(time.Duration)
+	return ok
+}
+
+// formatDuration renders a duration under the configured DurationStyle
+func formatDuration(value any, opts Options) string {
+	d := value.(time.Duration)
+	switch opts.Durations {
+	case DurationAsSeconds:
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64)
+	case DurationAsISO8601:
+		return formatISODuration(d)
+	default:
+		return d.String()
+	}
+}
+
+// formatISODuration renders d as an ISO 8601 duration using hours, minutes
+// and (fractional) seconds, e.g. "PT1H30M" or "-PT0.5S"
+func formatISODuration(d time.Duration) string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	// Work with the magnitude as uint64 so the minimum duration does not overflow
+	u := uint64(d)
+	if d < 0 {
+		b.WriteByte('-')
+		u = -u
+	}
+	b.WriteString("PT")
+
+	if h := u / uint64(time.Hour); h > 0 {
+		b.WriteString(strconv.FormatUint(h, 10) + "H")
+		u -= h * uint64(time.Hour)
+	}
+	if m := u / uint64(time.Minute); m > 0 {
+		b.WriteString(strconv.FormatUint(m, 10) + "M")
+		u -= m * uint64(time.Minute)
+	}
+	if u > 0 {
+		seconds := float64(u) / float64(time.Second)
+		b.WriteString(strconv.FormatFloat(seconds, 'f', -1, 64) + "S")
+	}
+	return b.String()
+}
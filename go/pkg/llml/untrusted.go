@@ -0,0 +1,103 @@
+package llml
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Untrusted marks v, typically user-submitted text, as untrusted content.
+// Text within it that looks like an opening or closing tag of the surrounding
+// document, such as "</instructions><instructions>", is neutralised by
+// escaping its angle brackets so it cannot forge structure. v may be a leaf
+// or a map, struct or slice, in which case every leaf below it is guarded.
+// Keys of untrusted maps still become tags; sanitise them with
+// Options.TagNames. Neutralised text is listed in Options.Report.
+//
+// Documents holding Untrusted values are rendered twice, first to learn their
+// tags, so LLMLMarshaler and LLMLValuer methods in them run twice.
+func Untrusted(v any) any {
+	return untrusted{value: v}
+}
+
+// untrusted wraps a value passed to Untrusted
+type untrusted struct {
+	value any
+}
+
+// Neutralization describes tag-like text found in an Untrusted value
+type Neutralization struct {
+	// Path is the data path of the leaf, e.g. "$.user_input"
+	Path string
+	// Tag is the text that was neutralised, e.g. "</instructions>"
+	Tag string
+}
+
+// untrustedPlaceholder stands in for untrusted leaf text while the tags of
+// the document are collected; it is never part of the output
+const untrustedPlaceholder = "untrusted"
+
+// taint marks the render context as untrusted when value is wrapped by
+// Untrusted, directly or inside Fenced
+func (o Options) taint(value any) Options {
+	if f, ok := value.(fenced); ok {
+		value = f.value
+	}
+	if _, ok := value.(untrusted); ok {
+		o.untrusted = true
+	}
+	return o
+}
+
+// markUntrusted neutralises the text of an untrusted leaf found at path.
+// Until the tags of the document are known (see encode), it records that
+// the render holds untrusted text and returns a placeholder instead.
+func markUntrusted(text, path string, opts Options) string {
+	// Empty text cannot forge anything, and callers may drop it
+	if text == "" {
+		return ""
+	}
+	state := opts.state
+	if !state.tagsKnown {
+		state.hasUntrusted = true
+		return untrustedPlaceholder
+	}
+	if state.forged == nil {
+		return text
+	}
+
+	return state.forged.ReplaceAllStringFunc(text, func(tag string) string {
+		if opts.Report != nil {
+			opts.Report.Neutralized = append(opts.Report.Neutralized, Neutralization{Path: path, Tag: tag})
+		}
+		return minimalEscaper.Replace(tag)
+	})
+}
+
+// tagPattern matches the names of tags written in trusted output
+var tagPattern = regexp.MustCompile(`</?([^\s<>&"'/=!?]+)`)
+
+// forgedTagPattern returns a pattern matching, case-insensitively, opening
+// and closing tags named like any tag in out, or nil if out has no tags
+func forgedTagPattern(out string) *regexp.Regexp {
+	names := map[string]bool{}
+	for _, m := range tagPattern.FindAllStringSubmatch(out, -1) {
+		names[regexp.QuoteMeta(m[1])] = true
+	}
+	if len(names) == 0 {
+		return nil
+	}
+
+	alternatives := make([]string, 0, len(names))
+	for name := range names {
+		alternatives = append(alternatives, name)
+	}
+	// Longer names first so "rules-1" is not matched as "rules"
+	sort.Slice(alternatives, func(i, j int) bool {
+		if len(alternatives[i]) != len(alternatives[j]) {
+			return len(alternatives[i]) > len(alternatives[j])
+		}
+		return alternatives[i] < alternatives[j]
+	})
+	return regexp.MustCompile(`(?i)<\s*/?\s*(?:` + strings.Join(alternatives, "|") + `)(?:\s[^<>]*)?/?\s*>`)
+}
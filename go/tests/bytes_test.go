@@ -0,0 +1,103 @@
+package llml_test
+
+import (
+	"encoding/json"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+func TestBytesAsTextByDefault(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"body": []byte("hello")})
+	expected := "<body>hello</body>"
+	assert.Equal(t, expected, result)
+}
+
+func TestInvalidUTF8FallsBackToBase64(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"blob": []byte{0xff, 0xfe, 0x00}})
+	expected := "<blob>//4A</blob>"
+	assert.Equal(t, expected, result)
+}
+
+func TestJSONRawMessage(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"payload": json.RawMessage(`{"ok":true}`)})
+	expected := `<payload>{"ok":true}</payload>`
+	assert.Equal(t, expected, result)
+}
+
+func TestBytesAsBase64AndHex(t *testing.T) {
+	data := map[string]any{"body": []byte("hello")}
+	assert.Equal(t, "<body>aGVsbG8=</body>", llml.Sprintf(data, llml.Options{Bytes: llml.BytesAsBase64}))
+	assert.Equal(t, "<body>68656c6c6f</body>", llml.Sprintf(data, llml.Options{Bytes: llml.BytesAsHex}))
+}
+
+func TestBytesAsSize(t *testing.T) {
+	opts := llml.Options{Bytes: llml.BytesAsSize}
+	result := llml.Sprintf(map[string]any{
+		"payload":     []byte(strings.Repeat("x", 2048)),
+		"attachments": [][]byte{[]byte("abc"), {}},
+	}, opts)
+	expected := "<attachments>\n" +
+		"  <attachments-1 bytes=\"3\"/>\n" +
+		"  <attachments-2 bytes=\"0\"/>\n" +
+		"</attachments>\n" +
+		"<payload bytes=\"2048\"/>"
+	assert.Equal(t, expected, result)
+
+	assert.Equal(t, "<1 bytes=\"5\"/>", llml.Sprintf([]json.RawMessage{json.RawMessage(`"abc"`)}, opts))
+}
+
+func TestBytesInStructFields(t *testing.T) {
+	type Upload struct {
+		Name    string  `llml:"name"`
+		Content []byte  `llml:"content"`
+		Digest  *[]byte `llml:"digest,omitempty"`
+	}
+	digest := []byte{0xde, 0xad}
+	result := llml.Sprintf(Upload{Name: "a.txt", Content: []byte("hi"), Digest: &digest}, llml.Options{Bytes: llml.BytesAsHex})
+	expected := "<name>a.txt</name>\n<content>6869</content>\n<digest>dead</digest>"
+	assert.Equal(t, expected, result)
+}
+
+func TestBytesSizeOnUntrustedValues(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"upload": llml.Untrusted([]byte("hello"))}, llml.Options{Bytes: llml.BytesAsSize})
+	assert.Equal(t, "<upload bytes=\"5\"/>", result)
+}
+
+type Payload []byte
+
+func TestNamedByteSlicesAndArrays(t *testing.T) {
+	digest := [4]byte{0xde, 0xad, 0xbe, 0xef}
+	result := llml.Sprintf(map[string]any{
+		"body":   Payload("hello"),
+		"digest": digest,
+		"ptr":    &digest,
+	}, llml.Options{Bytes: llml.BytesAsHex})
+	expected := "<body>68656c6c6f</body>\n<digest>deadbeef</digest>\n<ptr>deadbeef</ptr>"
+	assert.Equal(t, expected, result)
+
+	sized := llml.Sprintf(map[string]any{"hash": [32]byte{}}, llml.Options{Bytes: llml.BytesAsSize})
+	assert.Equal(t, "<hash bytes=\"32\"/>", sized)
+}
+
+func TestByteTypesWithTextMethodsUseThem(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"ip": net.IPv4(192, 168, 1, 1)})
+	assert.Equal(t, "<ip>192.168.1.1</ip>", result)
+}
+
+type Flag uint8
+
+func TestNamedUint8ElementsWithoutMethods(t *testing.T) {
+	assert.NotPanics(t, func() {
+		result := llml.Sprintf(map[string]any{"flags": []Flag{1, 2}})
+		assert.Equal(t, "<flags>\n  <flags-1>1</flags-1>\n  <flags-2>2</flags-2>\n</flags>", result)
+	})
+	assert.NotPanics(t, func() {
+		result, err := llml.MarshalString([2]Flag{1, 2})
+		assert.NoError(t, err)
+		assert.Equal(t, "<1>1</1>\n<2>2</2>", result)
+	})
+}
@@ -0,0 +1,81 @@
+package llml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type TaskNode struct {
+	Name     string      `llml:"name"`
+	Parent   *TaskNode   `llml:"parent,omitempty"`
+	Children []*TaskNode `llml:"children,omitempty"`
+}
+
+func newTaskTree() *TaskNode {
+	root := &TaskNode{Name: "deploy"}
+	child := &TaskNode{Name: "health-check", Parent: root}
+	root.Children = []*TaskNode{child}
+	return root
+}
+
+func TestCycleRendersRefPlaceholder(t *testing.T) {
+	result := llml.Sprintf(newTaskTree())
+	expected := "<name>deploy</name>\n" +
+		"<children>\n" +
+		"  <children-1>\n" +
+		"    <name>health-check</name>\n" +
+		"    <parent><ref path=\"$\"/></parent>\n" +
+		"  </children-1>\n" +
+		"</children>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCycleRefPathNamesAncestor(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"tree": newTaskTree()})
+	assert.Contains(t, result, "<parent><ref path=\"$.tree\"/></parent>")
+}
+
+func TestSelfReferencingMap(t *testing.T) {
+	m := map[string]any{"name": "loop"}
+	m["self"] = m
+	result := llml.Sprintf(m)
+	expected := "<name>loop</name>\n<self><ref path=\"$\"/></self>"
+	assert.Equal(t, expected, result)
+}
+
+func TestSelfReferencingSlice(t *testing.T) {
+	s := []any{"a", nil}
+	s[1] = s
+	result := llml.Sprintf(s)
+	expected := "<1>a</1>\n<2><ref path=\"$\"/></2>"
+	assert.Equal(t, expected, result)
+}
+
+func TestSharedReferencesAreNotCycles(t *testing.T) {
+	shared := &Config{Debug: true, Timeout: 5}
+	result := llml.Sprintf(map[string]any{
+		"configs": []*Config{shared, shared},
+		"primary": shared,
+	})
+	assert.NotContains(t, result, "<ref")
+	assert.Contains(t, result, "<configs-2>\n    <debug>true</debug>")
+	assert.Contains(t, result, "<primary>\n  <debug>true</debug>")
+}
+
+func TestCycleAsErrorStopsDescending(t *testing.T) {
+	result := llml.Sprintf(newTaskTree(), llml.Options{Cycles: llml.CycleAsError})
+	expected := "<name>deploy</name>\n" +
+		"<children>\n" +
+		"  <children-1>\n" +
+		"    <name>health-check</name>\n" +
+		"  </children-1>\n" +
+		"</children>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCycleErrorMessage(t *testing.T) {
+	err := &llml.CycleError{Path: "$.children.1.parent", Ref: "$"}
+	assert.Equal(t, "cycle detected at $.children.1.parent (refers back to $)", err.Error())
+}
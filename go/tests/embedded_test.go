@@ -0,0 +1,137 @@
+package llml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type BaseContext struct {
+	Role        string `llml:"role"`
+	Environment string `llml:"environment"`
+}
+
+type DeployRequest struct {
+	BaseContext
+	Task string `llml:"task"`
+}
+
+func TestEmbeddedStructFieldsArePromoted(t *testing.T) {
+	result := llml.Sprintf(DeployRequest{
+		BaseContext: BaseContext{Role: "DevOps agent", Environment: "production"},
+		Task:        "deploy",
+	})
+	expected := "<role>DevOps agent</role>\n" +
+		"<environment>production</environment>\n" +
+		"<task>deploy</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestEmbeddedPointerStruct(t *testing.T) {
+	type Request struct {
+		*BaseContext
+		Task string `llml:"task"`
+	}
+	result := llml.Sprintf(Request{
+		BaseContext: &BaseContext{Role: "agent", Environment: "staging"},
+		Task:        "rollback",
+	})
+	expected := "<role>agent</role>\n<environment>staging</environment>\n<task>rollback</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNilEmbeddedPointerIsSkipped(t *testing.T) {
+	type Request struct {
+		*BaseContext
+		Task string `llml:"task"`
+	}
+	result := llml.Sprintf(Request{Task: "rollback"})
+	expected := "<task>rollback</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTaggedEmbeddedStructIsNested(t *testing.T) {
+	type Request struct {
+		BaseContext `llml:"context"`
+		Task        string `llml:"task"`
+	}
+	result := llml.Sprintf(Request{
+		BaseContext: BaseContext{Role: "agent", Environment: "dev"},
+		Task:        "test",
+	})
+	expected := "<context>\n" +
+		"  <role>agent</role>\n" +
+		"  <environment>dev</environment>\n" +
+		"</context>\n" +
+		"<task>test</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestInlineTagOption(t *testing.T) {
+	type Request struct {
+		Context BaseContext `llml:",inline"`
+		Task    string      `llml:"task"`
+	}
+	result := llml.Sprintf(Request{
+		Context: BaseContext{Role: "agent", Environment: "dev"},
+		Task:    "test",
+	})
+	expected := "<role>agent</role>\n<environment>dev</environment>\n<task>test</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestUnexportedEmbeddedStructIsPromoted(t *testing.T) {
+	type audit struct {
+		Author string `llml:"author"`
+	}
+	type Change struct {
+		audit
+		Summary string `llml:"summary"`
+	}
+	result := llml.Sprintf(Change{audit: audit{Author: "alice"}, Summary: "fix"})
+	expected := "<author>alice</author>\n<summary>fix</summary>"
+	assert.Equal(t, expected, result)
+}
+
+func TestOuterFieldHidesPromotedField(t *testing.T) {
+	type Request struct {
+		BaseContext
+		Role string `llml:"role"`
+	}
+	result := llml.Sprintf(Request{
+		BaseContext: BaseContext{Role: "inner", Environment: "dev"},
+		Role:        "outer",
+	})
+	expected := "<environment>dev</environment>\n<role>outer</role>"
+	assert.Equal(t, expected, result)
+}
+
+func TestConflictingPromotedFieldsAreDropped(t *testing.T) {
+	type A struct{ Name string }
+	type B struct{ Name string }
+	type Both struct {
+		A
+		B
+		ID int
+	}
+	result := llml.Sprintf(Both{A: A{Name: "a"}, B: B{Name: "b"}, ID: 1})
+	expected := "<ID>1</ID>"
+	assert.Equal(t, expected, result)
+}
+
+func TestEmbeddedStructsInSlices(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"requests": []DeployRequest{
+			{BaseContext: BaseContext{Role: "agent", Environment: "prod"}, Task: "deploy"},
+		},
+	})
+	expected := "<requests>\n" +
+		"  <requests-1>\n" +
+		"    <role>agent</role>\n" +
+		"    <environment>prod</environment>\n" +
+		"    <task>deploy</task>\n" +
+		"  </requests-1>\n" +
+		"</requests>"
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,95 @@
+package llml_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+func TestKeyCaseFunctions(t *testing.T) {
+	cases := []struct {
+		key, kebab, snake, camel string
+	}{
+		{"XMLHttpRequest", "xml-http-request", "xml_http_request", "xmlHttpRequest"},
+		{"userID", "user-id", "user_id", "userId"},
+		{"user_name", "user-name", "user_name", "userName"},
+		{"user age", "user-age", "user_age", "userAge"},
+		{"HTMLElement", "html-element", "html_element", "htmlElement"},
+		{"getHTTPResponseCode", "get-http-response-code", "get_http_response_code", "getHttpResponseCode"},
+		{"item2Name", "item2-name", "item2_name", "item2Name"},
+		{"already-kebab", "already-kebab", "already_kebab", "alreadyKebab"},
+		{"ÜberName", "über-name", "über_name", "überName"},
+		{"42", "42", "42", "42"},
+		{"--", "--", "--", "--"},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.kebab, llml.KebabCase(c.key), c.key)
+		assert.Equal(t, c.snake, llml.SnakeCase(c.key), c.key)
+		assert.Equal(t, c.camel, llml.CamelCase(c.key), c.key)
+	}
+}
+
+func TestKeyCaseDefaultKeepsKeysVerbatim(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"userName": "alice", "user_email": "a@example.com"})
+	expected := "<userName>alice</userName>\n<user_email>a@example.com</user_email>"
+	assert.Equal(t, expected, result)
+}
+
+func TestKebabCaseAppliesToElementsAndListItems(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"userName":     "alice",
+		"AllowedHosts": []string{"10.0.0.1"},
+		"httpHeaders":  map[string]any{"ContentType": "json"},
+	}, llml.Options{KeyCase: llml.KebabCase})
+	expected := "<allowed-hosts>\n" +
+		"  <allowed-hosts-1>10.0.0.1</allowed-hosts-1>\n" +
+		"</allowed-hosts>\n" +
+		"<http-headers>  <content-type>json</content-type></http-headers>\n" +
+		"<user-name>alice</user-name>"
+	assert.Equal(t, expected, result)
+}
+
+func TestSnakeCaseWithPrefixAndStrictMode(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"maxRetries": 3,
+		"dbConfig":   map[string]any{"hostName": "localhost"},
+		"tags":       []string{"a"},
+	}, llml.Options{KeyCase: llml.SnakeCase, Prefix: "appConfig", Strict: true})
+	expected := "<app_config_db_config>  <app_config_db_config_host_name>localhost</app_config_db_config_host_name></app_config_db_config>\n" +
+		"<app_config_max_retries>3</app_config_max_retries>\n" +
+		"<app_config_tags>\n" +
+		"  <app_config_tags_1>a</app_config_tags_1>\n" +
+		"</app_config_tags>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCamelCaseInDirectSlices(t *testing.T) {
+	result := llml.Sprintf([]any{map[string]any{"first_name": "Ada"}}, llml.Options{KeyCase: llml.CamelCase})
+	expected := "<1>\n  <1FirstName>Ada</1FirstName>\n</1>"
+	assert.Equal(t, expected, result)
+}
+
+func TestKeyCaseAppliesToStructFields(t *testing.T) {
+	type Profile struct {
+		DisplayName string
+		HomeURL     string `llml:"home_url"`
+	}
+	result := llml.Sprintf(Profile{DisplayName: "Ada", HomeURL: "https://example.com"}, llml.Options{KeyCase: llml.KebabCase})
+	expected := "<display-name>Ada</display-name>\n<home-url>https://example.com</home-url>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCustomKeyCase(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"task": "x"}, llml.Options{KeyCase: strings.ToUpper})
+	assert.Equal(t, "<TASK>x</TASK>", result)
+}
+
+func TestKeyCaseKeepsDataPaths(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{"eventHandlers": map[string]any{"onClick": func() {}}}, llml.Options{KeyCase: llml.KebabCase})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.eventHandlers.onClick", marshalErr.Path)
+}
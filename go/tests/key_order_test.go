@@ -0,0 +1,94 @@
+package llml_test
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+func TestLexicalOrderIsDefault(t *testing.T) {
+	data := map[string]any{"item10": 10, "item2": 2, "item1": 1}
+	expected := "<item1>1</item1>\n<item10>10</item10>\n<item2>2</item2>"
+	assert.Equal(t, expected, llml.Sprintf(data))
+	assert.Equal(t, expected, llml.Sprintf(data, llml.Options{KeyOrder: llml.LexicalOrder}))
+}
+
+func TestNaturalOrder(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"item10": 10, "item2": 2, "item1": 1, "item": 0}, llml.Options{KeyOrder: llml.NaturalOrder})
+	expected := "<item>0</item>\n<item1>1</item1>\n<item2>2</item2>\n<item10>10</item10>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNaturalOrderCompare(t *testing.T) {
+	keys := []string{"step10b", "step10a", "step9", "v1.10", "v1.2", "file007", "file7", "file08", "a"}
+	sort.Slice(keys, func(i, j int) bool { return llml.NaturalOrder(keys[i], keys[j]) < 0 })
+	assert.Equal(t, []string{"a", "file007", "file7", "file08", "step9", "step10a", "step10b", "v1.2", "v1.10"}, keys)
+}
+
+func TestPriorityOrder(t *testing.T) {
+	data := map[string]any{
+		"context": "ctx",
+		"zeta":    "z",
+		"alpha":   "a",
+		"task":    "do it",
+		"role":    "analyst",
+	}
+	result := llml.Sprintf(data, llml.Options{KeyOrder: llml.PriorityOrder("role", "task", "context")})
+	expected := "<role>analyst</role>\n<task>do it</task>\n<context>ctx</context>\n<alpha>a</alpha>\n<zeta>z</zeta>"
+	assert.Equal(t, expected, result)
+}
+
+func TestPriorityOrderAppliesAtDepth(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"messages": []any{map[string]any{"content": "hi", "role": "user"}},
+	}, llml.Options{KeyOrder: llml.PriorityOrder("role")})
+	assert.Contains(t, result, "<role>user</role>\n    <content>hi</content>")
+}
+
+func TestCustomKeyOrder(t *testing.T) {
+	byLength := func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	}
+	result := llml.Sprintf(map[string]int{"ccc": 3, "a": 1, "bb": 2}, llml.Options{KeyOrder: byLength})
+	expected := "<a>1</a>\n<bb>2</bb>\n<ccc>3</ccc>"
+	assert.Equal(t, expected, result)
+}
+
+func TestPartialKeyOrderIsDeterministic(t *testing.T) {
+	roleFirst := func(a, b string) int {
+		switch {
+		case a == "role":
+			return -1
+		case b == "role":
+			return 1
+		}
+		return 0
+	}
+	data := map[string]int{"task": 1, "context": 2, "role": 3, "examples": 4, "format": 5}
+	expected := "<role>3</role>\n<context>2</context>\n<examples>4</examples>\n<format>5</format>\n<task>1</task>"
+	for i := 0; i < 50; i++ {
+		assert.Equal(t, expected, llml.Sprintf(data, llml.Options{KeyOrder: roleFirst}))
+	}
+}
+
+func TestKeyOrderAppliesToIntKeys(t *testing.T) {
+	data := map[int]string{2: "b", 10: "c", 1: "a"}
+	assert.Equal(t, "<1>a</1>\n<2>b</2>\n<10>c</10>", llml.Sprintf(data))
+	assert.Equal(t, "<1>a</1>\n<10>c</10>\n<2>b</2>", llml.Sprintf(data, llml.Options{KeyOrder: llml.LexicalOrder}))
+}
+
+func TestKeyOrderLeavesStructsAndOrderedAlone(t *testing.T) {
+	type Prompt struct {
+		Task    string `llml:"task"`
+		Context string `llml:"context"`
+	}
+	opts := llml.Options{KeyOrder: llml.LexicalOrder}
+	assert.Equal(t, "<task>t</task>\n<context>c</context>", llml.Sprintf(Prompt{Task: "t", Context: "c"}, opts))
+	assert.Equal(t, "<task>t</task>\n<context>c</context>", llml.Sprintf(llml.KV("task", "t", "context", "c"), opts))
+}
@@ -0,0 +1,73 @@
+package llml_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type Rule struct {
+	Text string `llml:"text"`
+}
+
+type Environment string
+
+func TestIntKeysSortNumerically(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"rules": map[int]Rule{10: {Text: "be concise"}, 2: {Text: "cite sources"}, -1: {Text: "be kind"}},
+	})
+	expected := "<rules>\n" +
+		"  <-1>    <text>be kind</text></-1>\n" +
+		"  <2>    <text>cite sources</text></2>\n" +
+		"  <10>    <text>be concise</text></10>\n" +
+		"</rules>"
+	assert.Equal(t, expected, result)
+}
+
+func TestUintKeysSortNumerically(t *testing.T) {
+	result := llml.Sprintf(map[uint8]string{20: "b", 3: "a"})
+	expected := "<3>a</3>\n<20>b</20>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCustomStringKeys(t *testing.T) {
+	result := llml.Sprintf(map[Environment]int{"staging": 2, "prod": 5})
+	expected := "<prod>5</prod>\n<staging>2</staging>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTextMarshalerKeys(t *testing.T) {
+	result := llml.Sprintf(map[Region]Config{
+		{Code: "us"}: {Debug: false, Timeout: 30},
+		{Code: "eu"}: {Debug: true, Timeout: 10},
+	})
+	expected := "<region:eu>\n" +
+		"  <debug>true</debug>\n" +
+		"  <timeout>10</timeout>\n" +
+		"</region:eu>\n" +
+		"<region:us>\n" +
+		"  <debug>false</debug>\n" +
+		"  <timeout>30</timeout>\n" +
+		"</region:us>"
+	assert.Equal(t, expected, result)
+}
+
+func TestIntKeysInStrictMode(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"steps": map[int]string{1: "plan", 2: "act"}}, llml.Options{Strict: true})
+	expected := "<steps>\n  <steps-1>plan</steps-1>\n  <steps-2>act</steps-2>\n</steps>"
+	assert.Equal(t, expected, result)
+}
+
+func TestMarshalIntKeyPaths(t *testing.T) {
+	_, err := llml.MarshalString(map[int]any{7: func() {}})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.7", marshalErr.Path)
+}
+
+func TestTextMarshalerKeyError(t *testing.T) {
+	_, err := llml.MarshalString(map[BadText]int{{Name: "x"}: 1})
+	assert.EqualError(t, err, "llml: cannot marshal $.{x}: not today")
+}
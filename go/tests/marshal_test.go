@@ -0,0 +1,101 @@
+package llml_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+func TestMarshalMatchesSprintf(t *testing.T) {
+	data := map[string]any{
+		"task":  "analyze",
+		"rules": []string{"be concise", "be accurate"},
+	}
+	out, err := llml.Marshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, llml.Sprintf(data), string(out))
+}
+
+func TestMarshalStringWithOptions(t *testing.T) {
+	out, err := llml.MarshalString(map[string]any{"port": 5432}, llml.Options{Prefix: "app"})
+	assert.NoError(t, err)
+	assert.Equal(t, "<app-port>5432</app-port>", out)
+}
+
+func TestMarshalUnsupportedType(t *testing.T) {
+	data := map[string]any{
+		"config": map[string]any{
+			"callback": func() {},
+		},
+	}
+	out, err := llml.Marshal(data)
+	assert.Nil(t, out)
+
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.config.callback", marshalErr.Path)
+
+	var typeErr *llml.UnsupportedTypeError
+	assert.True(t, errors.As(err, &typeErr))
+	assert.Equal(t, "func()", typeErr.Type.String())
+	assert.Equal(t, "llml: cannot marshal $.config.callback: unsupported type func()", err.Error())
+}
+
+func TestMarshalUnsupportedListItem(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{
+		"queues": []any{"ok", make(chan int)},
+	})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.queues.2", marshalErr.Path)
+}
+
+func TestMarshalUnsupportedRoot(t *testing.T) {
+	_, err := llml.MarshalString(make(chan string))
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$", marshalErr.Path)
+}
+
+func TestMarshalUnrenderableMapKeys(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{
+		"weights": map[float64]string{0.5: "half"},
+	})
+	var typeErr *llml.UnsupportedTypeError
+	assert.True(t, errors.As(err, &typeErr))
+}
+
+func TestMarshalInvalidTagName(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{
+		"user": map[string]any{"key with spaces": "value"},
+	})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.user.key with spaces", marshalErr.Path)
+
+	var tagErr *llml.InvalidTagNameError
+	assert.True(t, errors.As(err, &tagErr))
+	assert.Equal(t, "key with spaces", tagErr.Name)
+}
+
+func TestMarshalCycleError(t *testing.T) {
+	_, err := llml.MarshalString(newTaskTree(), llml.Options{Cycles: llml.CycleAsError})
+	var cycleErr *llml.CycleError
+	assert.True(t, errors.As(err, &cycleErr))
+	assert.Equal(t, "$.children.1.parent", cycleErr.Path)
+	assert.Equal(t, "$", cycleErr.Ref)
+}
+
+func TestMarshalCycleRefIsNotAnError(t *testing.T) {
+	out, err := llml.MarshalString(newTaskTree())
+	assert.NoError(t, err)
+	assert.Contains(t, out, "<ref path=\"$\"/>")
+}
+
+func TestSprintfStaysLenient(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"key with spaces": "value"})
+	assert.Equal(t, "<key with spaces>value</key with spaces>", result)
+	assert.Equal(t, "<key with spaces>value</key with spaces>", llml.LLML(map[string]any{"key with spaces": "value"}))
+}
@@ -0,0 +1,153 @@
+package llml_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type Money struct {
+	Cents    int64
+	Currency string
+}
+
+func (m Money) LLMLValue() (any, error) {
+	return fmt.Sprintf("%d.%02d %s", m.Cents/100, m.Cents%100, m.Currency), nil
+}
+
+type TicketID int
+
+func (id TicketID) LLMLValue() (any, error) {
+	return fmt.Sprintf("TICKET-%d", int(id)), nil
+}
+
+type Message struct {
+	Role string
+	Text string
+}
+
+type Conversation struct {
+	Messages []Message
+}
+
+func (c *Conversation) MarshalLLML(enc *llml.Encoder) error {
+	for _, m := range c.Messages {
+		if err := enc.EncodeElement(m.Role, m.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type Broken struct{}
+
+func (Broken) MarshalLLML(enc *llml.Encoder) error {
+	return errors.New("boom")
+}
+
+func TestValuerRendersReplacement(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"price":  Money{Cents: 1250, Currency: "USD"},
+		"ticket": TicketID(42),
+	})
+	expected := "<price>12.50 USD</price>\n<ticket>TICKET-42</ticket>"
+	assert.Equal(t, expected, result)
+}
+
+func TestValuerInLists(t *testing.T) {
+	result := llml.Sprintf([]TicketID{1, 2})
+	expected := "<1>TICKET-1</1>\n<2>TICKET-2</2>"
+	assert.Equal(t, expected, result)
+}
+
+func TestMarshalerWritesElements(t *testing.T) {
+	conv := &Conversation{Messages: []Message{
+		{Role: "user", Text: "Hi"},
+		{Role: "assistant", Text: "Hello!"},
+	}}
+	result := llml.Sprintf(map[string]any{"conversation": conv})
+	expected := "<conversation>\n" +
+		"  <user>Hi</user>\n" +
+		"  <assistant>Hello!</assistant>\n" +
+		"</conversation>"
+	assert.Equal(t, expected, result)
+}
+
+func TestMarshalerAtRoot(t *testing.T) {
+	conv := &Conversation{Messages: []Message{{Role: "user", Text: "Hi"}}}
+	assert.Equal(t, "<user>Hi</user>", llml.Sprintf(conv))
+}
+
+func TestMarshalerInDirectSlice(t *testing.T) {
+	result := llml.Sprintf([]*Conversation{
+		{Messages: []Message{{Role: "user", Text: "Hi"}}},
+	})
+	expected := "<1>\n  <1-user>Hi</1-user>\n</1>"
+	assert.Equal(t, expected, result)
+}
+
+func TestPointerReceiverRequiresPointer(t *testing.T) {
+	conv := Conversation{Messages: []Message{{Role: "user", Text: "Hi"}}}
+	result := llml.Sprintf(map[string]any{"conversation": conv})
+	assert.Contains(t, result, "<Messages>")
+}
+
+func TestEncoderEncodeRendersInPlace(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"summary": encodeFunc(func(enc *llml.Encoder) error {
+			return enc.Encode(map[string]any{"a": 1, "b": 2})
+		}),
+	})
+	expected := "<summary>\n  <a>1</a>\n  <b>2</b>\n</summary>"
+	assert.Equal(t, expected, result)
+}
+
+func TestMarshalerErrorIsReported(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{"item": Broken{}})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.item", marshalErr.Path)
+	assert.EqualError(t, err, "llml: cannot marshal $.item: boom")
+}
+
+func TestEncoderPropagatesNestedErrors(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{
+		"outer": encodeFunc(func(enc *llml.Encoder) error {
+			return enc.EncodeElement("callback", func() {})
+		}),
+	})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.outer.callback", marshalErr.Path)
+}
+
+type encodeFunc func(enc *llml.Encoder) error
+
+func (f encodeFunc) MarshalLLML(enc *llml.Encoder) error {
+	return f(enc)
+}
+
+type Price int64
+
+func (p Price) MarshalLLML(enc *llml.Encoder) error {
+	return enc.Encode(fmt.Sprintf("$%d.%02d", p/100, p%100))
+}
+
+func TestListOfTextMarshalers(t *testing.T) {
+	assert.Equal(t, "<price>$12.00</price>", llml.Sprintf(map[string]any{"price": Price(1200)}))
+
+	result := llml.Sprintf(map[string]any{"prices": []Price{100, 250}})
+	expected := "<prices>\n" +
+		"  <prices-1>$1.00</prices-1>\n" +
+		"  <prices-2>$2.50</prices-2>\n" +
+		"</prices>"
+	assert.Equal(t, expected, result)
+
+	assert.Equal(t, "<1>$1.00</1>\n<2>$2.50</2>", llml.Sprintf([]Price{100, 250}))
+
+	nested := llml.Sprintf(map[string]any{"tiers": [][]Price{{100, 200}}})
+	assert.Equal(t, "<tiers>\n  <tiers-1><1>$1.00</1>\n<2>$2.00</2></tiers-1>\n</tiers>", nested)
+}
@@ -0,0 +1,127 @@
+package llml_test
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+func TestFloatDefaultIsShortestRoundTrip(t *testing.T) {
+	a, b := 0.1, 0.2
+	assert.Equal(t, "0.30000000000000004", llml.Sprintf(a+b))
+	assert.Equal(t, "1e+21", llml.Sprintf(1e21))
+	assert.Equal(t, "0.5", llml.Sprintf(float32(0.5)))
+}
+
+func TestFloatFixedPrecision(t *testing.T) {
+	opts := llml.Options{Floats: llml.FloatFormat{Fixed: true, Precision: 2}}
+	a, b := 0.1, 0.2
+	result := llml.Sprintf(map[string]any{
+		"total": a + b,
+		"items": []float64{1, 2.005, 1234567.891},
+	}, opts)
+	expected := "<items>\n" +
+		"  <items-1>1.00</items-1>\n" +
+		"  <items-2>2.00</items-2>\n" +
+		"  <items-3>1234567.89</items-3>\n" +
+		"</items>\n" +
+		"<total>0.30</total>"
+	assert.Equal(t, expected, result)
+
+	whole := llml.Options{Floats: llml.FloatFormat{Fixed: true}}
+	assert.Equal(t, "3", llml.Sprintf(2.7, whole))
+}
+
+func TestFloatExponentThresholds(t *testing.T) {
+	opts := llml.Options{Floats: llml.FloatFormat{ExponentAbove: 1e21, ExponentBelow: 1e-6}}
+	assert.Equal(t, "100000000000000000000", llml.Sprintf(1e20, opts))
+	assert.Equal(t, "1e+21", llml.Sprintf(1e21, opts))
+	assert.Equal(t, "1234567.5", llml.Sprintf(1234567.5, opts))
+	assert.Equal(t, "0.000001", llml.Sprintf(1e-6, opts))
+	assert.Equal(t, "5e-07", llml.Sprintf(5e-7, opts))
+	assert.Equal(t, "0", llml.Sprintf(0.0, opts))
+
+	fixed := llml.Options{Floats: llml.FloatFormat{Fixed: true, Precision: 1, ExponentAbove: 1e6}}
+	assert.Equal(t, "2.5e+06", llml.Sprintf(2.5e6, fixed))
+}
+
+func TestNaNAsText(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"nan": math.NaN(),
+		"inf": math.Inf(1),
+		"neg": math.Inf(-1),
+	})
+	expected := "<inf>+Inf</inf>\n<nan>NaN</nan>\n<neg>-Inf</neg>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNaNOmit(t *testing.T) {
+	opts := llml.Options{NaN: llml.NaNOmit}
+	result := llml.Sprintf(map[string]any{
+		"score":   math.NaN(),
+		"ratio":   0.5,
+		"history": []float64{1, math.Inf(1), 3},
+	}, opts)
+	expected := "<history>\n" +
+		"  <history-1>1</history-1>\n" +
+		"  <history-3>3</history-3>\n" +
+		"</history>\n" +
+		"<ratio>0.5</ratio>"
+	assert.Equal(t, expected, result)
+
+	assert.Equal(t, "<1>1</1>", llml.Sprintf([]any{1, math.NaN()}, opts))
+}
+
+func TestNaNAsError(t *testing.T) {
+	data := map[string]any{"metrics": map[string]any{"latency": math.Inf(1)}}
+	opts := llml.Options{NaN: llml.NaNAsError}
+
+	assert.Equal(t, "<metrics>  <latency>+Inf</latency></metrics>", llml.Sprintf(data, opts))
+
+	_, err := llml.MarshalString(data, opts)
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.metrics.latency", marshalErr.Path)
+	var valueErr *llml.UnsupportedValueError
+	assert.True(t, errors.As(err, &valueErr))
+	assert.EqualError(t, err, "llml: cannot marshal $.metrics.latency: unsupported value +Inf")
+}
+
+func TestBigNumbers(t *testing.T) {
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	precise, _ := new(big.Float).SetPrec(200).SetString("3.14159265358979323846264338327950288")
+	result := llml.Sprintf(map[string]any{
+		"huge":    huge,
+		"precise": precise,
+		"third":   big.NewRat(1, 3),
+		"whole":   big.NewRat(4, 2),
+	})
+	expected := "<huge>123456789012345678901234567890</huge>\n" +
+		"<precise>3.14159265358979323846264338327950288</precise>\n" +
+		"<third>1/3</third>\n" +
+		"<whole>2</whole>"
+	assert.Equal(t, expected, result)
+}
+
+func TestBigNumbersHonourFixedPrecision(t *testing.T) {
+	opts := llml.Options{Floats: llml.FloatFormat{Fixed: true, Precision: 4}}
+	assert.Equal(t, "0.3333", llml.Sprintf(big.NewRat(1, 3), opts))
+	assert.Equal(t, "2.5000", llml.Sprintf(big.NewFloat(2.5), opts))
+}
+
+func TestJSONNumberIsVerbatim(t *testing.T) {
+	decoder := json.NewDecoder(strings.NewReader(`{"id": 12345678901234567890, "ratio": 0.10}`))
+	decoder.UseNumber()
+	var data map[string]any
+	assert.NoError(t, decoder.Decode(&data))
+
+	result := llml.Sprintf(data)
+	expected := "<id>12345678901234567890</id>\n<ratio>0.10</ratio>"
+	assert.Equal(t, expected, result)
+}
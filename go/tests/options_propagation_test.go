@@ -0,0 +1,92 @@
+package llml_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+// opaqueText has no exported fields, so it is rendered as a leaf
+type opaqueText struct {
+	code string
+}
+
+func (o opaqueText) MarshalText() ([]byte, error) {
+	return []byte("text:" + o.code), nil
+}
+
+type failingText struct {
+	code string
+}
+
+func (failingText) MarshalText() ([]byte, error) {
+	return nil, errors.New("unavailable")
+}
+
+func deeplyNested(leaf any) map[string]any {
+	return map[string]any{
+		"a": map[string]any{
+			"b": []any{
+				[]any{leaf},
+				map[string]any{"c": []any{leaf}},
+			},
+		},
+	}
+}
+
+func TestTextMethodsDisabledAtDepth(t *testing.T) {
+	opts := llml.Options{TextMethods: []llml.TextMethod{}}
+	assert.Equal(t, "{eu}", llml.Sprintf(opaqueText{code: "eu"}, opts))
+
+	result := llml.Sprintf(deeplyNested(opaqueText{code: "eu"}), opts)
+	assert.NotContains(t, result, "text:eu")
+	assert.Contains(t, result, "<b-1><1>{eu}</1></b-1>")
+	assert.Contains(t, result, "<c-1>{eu}</c-1>")
+}
+
+func TestRegistryAppliesToDeeplyNestedLeaves(t *testing.T) {
+	registry := llml.DefaultRegistry().Register(isEmail, formatEmail)
+	result := llml.Sprintf(deeplyNested(Email("Ops@Example.com")), llml.Options{Registry: registry})
+	expected := "<a>\n" +
+		"  <b>\n" +
+		"    <b-1><1>mailto:ops@example.com</1></b-1>\n" +
+		"    <b-2>\n" +
+		"      <c>\n" +
+		"        <c-1>mailto:ops@example.com</c-1>\n" +
+		"      </c>\n" +
+		"    </b-2>\n" +
+		"  </b>\n" +
+		"</a>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNilStyleAppliesToDeeplyNestedLeaves(t *testing.T) {
+	result := llml.Sprintf(deeplyNested(nil), llml.Options{Nil: llml.NilAsNull})
+	assert.NotContains(t, result, "nil")
+	assert.Contains(t, result, "<1>null</1>")
+	assert.Contains(t, result, "<c-1>null</c-1>")
+}
+
+func TestStrictModeAtDepth(t *testing.T) {
+	result := llml.Sprintf([]any{deeplyNested("x")}, llml.Options{Strict: true})
+	assert.Contains(t, result, "<1-a-b-2-c-1>x</1-a-b-2-c-1>")
+}
+
+func TestMarshalReportsDeeplyNestedLeafErrors(t *testing.T) {
+	_, err := llml.MarshalString(deeplyNested(failingText{code: "x"}))
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.a.b.1.1", marshalErr.Path)
+	assert.EqualError(t, err, "llml: cannot marshal $.a.b.1.1: unavailable")
+}
+
+func TestMarshalReportsDeeplyNestedUnsupportedLeaves(t *testing.T) {
+	_, err := llml.MarshalString(map[string]any{
+		"a": []any{map[string]any{"b": []any{"ok", func() {}}}},
+	})
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.a.1.b.2", marshalErr.Path)
+}
@@ -0,0 +1,87 @@
+package llml_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+func TestOrderedKeepsInsertionOrder(t *testing.T) {
+	result := llml.Sprintf(llml.KV(
+		"task", "Summarize the ticket",
+		"rules", []string{"be brief"},
+		"context", "Customer reports login failures",
+	))
+	expected := "<task>Summarize the ticket</task>\n" +
+		"<rules>\n" +
+		"  <rules-1>be brief</rules-1>\n" +
+		"</rules>\n" +
+		"<context>Customer reports login failures</context>"
+	assert.Equal(t, expected, result)
+}
+
+func TestOrderedNestedInMapsAndLists(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"steps": []any{
+			llml.KV("name", "plan", "after", "start"),
+		},
+		"prompt": llml.KV("z", 1, "a", 2),
+	})
+	expected := "<prompt>\n" +
+		"  <z>1</z>\n" +
+		"  <a>2</a>\n" +
+		"</prompt>\n" +
+		"<steps>\n" +
+		"  <steps-1>\n" +
+		"    <name>plan</name>\n" +
+		"    <after>start</after>\n" +
+		"  </steps-1>\n" +
+		"</steps>"
+	assert.Equal(t, expected, result)
+}
+
+func TestOrderedContainingMaps(t *testing.T) {
+	result := llml.Sprintf(llml.KV(
+		"role", "analyst",
+		"config", map[string]any{"b": 2, "a": 1},
+	))
+	expected := "<role>analyst</role>\n<config>\n  <a>1</a>\n  <b>2</b>\n</config>"
+	assert.Equal(t, expected, result)
+}
+
+func TestOrderedInDirectSliceAndStrictMode(t *testing.T) {
+	assert.Equal(t, "<1>\n  <1-b>x</1-b>\n  <1-a>y</1-a>\n</1>",
+		llml.Sprintf([]llml.Ordered{llml.KV("b", "x", "a", "y")}))
+
+	result := llml.Sprintf(llml.KV("outer", llml.KV("b", 1, "a", 2)), llml.Options{Strict: true})
+	expected := "<outer>\n  <outer-b>1</outer-b>\n  <outer-a>2</outer-a>\n</outer>"
+	assert.Equal(t, expected, result)
+}
+
+func TestOrderedSet(t *testing.T) {
+	o := llml.KV("task", "draft", "tone", "formal")
+	o = o.Set("task", "review").Set("audience", "legal")
+	assert.Equal(t, "<task>review</task>\n<tone>formal</tone>\n<audience>legal</audience>", llml.Sprintf(o))
+}
+
+func TestOrderedEmptyAndNil(t *testing.T) {
+	assert.Equal(t, "", llml.Sprintf(llml.Ordered{}))
+	var missing llml.Ordered
+	assert.Equal(t, "<context>nil</context>", llml.Sprintf(llml.KV("context", missing)))
+}
+
+func TestKVPanicsOnBadArguments(t *testing.T) {
+	assert.Panics(t, func() { llml.KV("task") })
+	assert.Panics(t, func() { llml.KV(1, "task") })
+}
+
+func TestOrderedReportsNestedErrors(t *testing.T) {
+	_, err := llml.MarshalString(llml.KV("ok", 1, "callback", func() {}, "after", 2))
+	var marshalErr *llml.MarshalError
+	assert.True(t, errors.As(err, &marshalErr))
+	assert.Equal(t, "$.callback", marshalErr.Path)
+
+	assert.Contains(t, llml.Sprintf(llml.KV("callback", func() {}, "after", 2)), "<after>2</after>")
+}
@@ -0,0 +1,130 @@
+package llml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type Config struct {
+	Debug   bool `llml:"debug"`
+	Timeout int  `llml:"timeout"`
+}
+
+type OptionalFields struct {
+	Name   *string `llml:"name"`
+	Config *Config `llml:"config"`
+	Tags   []string
+}
+
+func TestPointerToPrimitive(t *testing.T) {
+	name := "Alice"
+	result := llml.Sprintf(map[string]any{"name": &name})
+	expected := "<name>Alice</name>"
+	assert.Equal(t, expected, result)
+}
+
+func TestPointerToPointer(t *testing.T) {
+	count := 3
+	ptr := &count
+	result := llml.Sprintf(map[string]any{"count": &ptr})
+	expected := "<count>3</count>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDirectPointerToStruct(t *testing.T) {
+	result := llml.Sprintf(&Config{Debug: true, Timeout: 30})
+	expected := "<debug>true</debug>\n<timeout>30</timeout>"
+	assert.Equal(t, expected, result)
+}
+
+func TestPointerFieldsInStruct(t *testing.T) {
+	name := "Bob"
+	result := llml.Sprintf(OptionalFields{
+		Name:   &name,
+		Config: &Config{Timeout: 10},
+		Tags:   []string{"x"},
+	})
+	expected := "<name>Bob</name>\n" +
+		"<config>\n" +
+		"  <debug>false</debug>\n" +
+		"  <timeout>10</timeout>\n" +
+		"</config>\n" +
+		"<Tags>\n" +
+		"  <Tags-1>x</Tags-1>\n" +
+		"</Tags>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTypedNilsRenderAsNil(t *testing.T) {
+	result := llml.Sprintf(OptionalFields{})
+	expected := "<name>nil</name>\n<config>nil</config>\n<Tags>nil</Tags>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDirectTypedNilPointer(t *testing.T) {
+	var cfg *Config
+	assert.Equal(t, "nil", llml.Sprintf(cfg))
+	assert.Equal(t, "null", llml.Sprintf(cfg, llml.Options{Nil: llml.NilAsNull}))
+}
+
+func TestNilStyleNull(t *testing.T) {
+	var m map[string]any
+	result := llml.Sprintf(map[string]any{
+		"missing": nil,
+		"nilMap":  m,
+	}, llml.Options{Nil: llml.NilAsNull})
+	expected := "<missing>null</missing>\n<nilMap>null</nilMap>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNilStyleEmpty(t *testing.T) {
+	result := llml.Sprintf(OptionalFields{}, llml.Options{Nil: llml.NilAsEmpty})
+	expected := "<name></name>\n<config></config>\n<Tags></Tags>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNilStyleOmit(t *testing.T) {
+	name := "Carol"
+	result := llml.Sprintf(OptionalFields{Name: &name}, llml.Options{Nil: llml.NilOmit})
+	expected := "<name>Carol</name>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNilStyleInLists(t *testing.T) {
+	var missing *string
+	data := map[string]any{
+		"items": []any{"a", missing, nil},
+	}
+	assert.Equal(t,
+		"<items>\n  <items-1>a</items-1>\n  <items-2>null</items-2>\n  <items-3>null</items-3>\n</items>",
+		llml.Sprintf(data, llml.Options{Nil: llml.NilAsNull}))
+	assert.Equal(t,
+		"<items>\n  <items-1>a</items-1>\n</items>",
+		llml.Sprintf(data, llml.Options{Nil: llml.NilOmit}))
+}
+
+func TestNilStyleInDirectSlice(t *testing.T) {
+	data := []any{"a", nil, "c"}
+	assert.Equal(t, "<1>a</1>\n<2>nil</2>\n<3>c</3>", llml.Sprintf(data))
+	assert.Equal(t, "<1>a</1>\n<3>c</3>", llml.Sprintf(data, llml.Options{Nil: llml.NilOmit}))
+}
+
+func TestNilStylePropagatesToNestedMaps(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"config": map[string]any{
+			"debug":   true,
+			"profile": (*Config)(nil),
+		},
+	}, llml.Options{Nil: llml.NilOmit})
+	expected := "<config>  <debug>true</debug></config>"
+	assert.Equal(t, expected, result)
+}
+
+func TestInterfaceValuesAreUnwrapped(t *testing.T) {
+	var inner any = &Config{Debug: true}
+	result := llml.Sprintf([]any{inner})
+	expected := "<1>\n  <1-debug>true</1-debug>\n  <1-timeout>0</1-timeout>\n</1>"
+	assert.Equal(t, expected, result)
+}
@@ -0,0 +1,137 @@
+package llml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+// Typed collections should render exactly like their map[string]any / []any equivalents
+
+type Label string
+
+func TestTypedStringSlice(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"rules": []string{"first", "second", "third"},
+	})
+	expected := llml.Sprintf(map[string]any{
+		"rules": []any{"first", "second", "third"},
+	})
+	assert.Equal(t, expected, result)
+	assert.Equal(t, "<rules>\n  <rules-1>first</rules-1>\n  <rules-2>second</rules-2>\n  <rules-3>third</rules-3>\n</rules>", result)
+}
+
+func TestDirectTypedSlice(t *testing.T) {
+	result := llml.Sprintf([]string{"a", "b", "c"})
+	expected := "<1>a</1>\n<2>b</2>\n<3>c</3>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDirectArrayKind(t *testing.T) {
+	result := llml.Sprintf([3]int{1, 2, 3})
+	expected := "<1>1</1>\n<2>2</2>\n<3>3</3>"
+	assert.Equal(t, expected, result)
+}
+
+func TestArrayKindInMap(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"scores": [2]float64{0.5, 0.75},
+	})
+	expected := "<scores>\n  <scores-1>0.5</scores-1>\n  <scores-2>0.75</scores-2>\n</scores>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTypedStringMap(t *testing.T) {
+	result := llml.Sprintf(map[string]string{
+		"task": "analyze",
+		"mode": "fast",
+	})
+	expected := "<mode>fast</mode>\n<task>analyze</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNamedStringKeys(t *testing.T) {
+	result := llml.Sprintf(map[Label]int{
+		"beta":  2,
+		"alpha": 1,
+	})
+	expected := "<alpha>1</alpha>\n<beta>2</beta>"
+	assert.Equal(t, expected, result)
+}
+
+func TestMapOfTypedSlices(t *testing.T) {
+	result := llml.Sprintf(map[string][]map[string]string{
+		"docs": {
+			{"title": "Guide"},
+			{"title": "FAQ"},
+		},
+	})
+	expected := llml.Sprintf(map[string]any{
+		"docs": []any{
+			map[string]any{"title": "Guide"},
+			map[string]any{"title": "FAQ"},
+		},
+	})
+	assert.Equal(t, expected, result)
+	assert.Equal(t, "<docs>\n  <docs-1>\n    <title>Guide</title>\n  </docs-1>\n  <docs-2>\n    <title>FAQ</title>\n  </docs-2>\n</docs>", result)
+}
+
+func TestNestedTypedMap(t *testing.T) {
+	result := llml.Sprintf(map[string]map[string]int{
+		"config": {"timeout": 30, "retries": 3},
+	}, llml.Options{Strict: true})
+	expected := "<config>\n  <config-retries>3</config-retries>\n  <config-timeout>30</config-timeout>\n</config>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDirectSliceOfTypedMaps(t *testing.T) {
+	result := llml.Sprintf([]map[string]string{{"name": "Alice"}, {}})
+	expected := "<1>\n  <1-name>Alice</1-name>\n</1>\n<2></2>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDirectSliceOfTypedSlices(t *testing.T) {
+	result := llml.Sprintf([][]int{{1, 2}, {}})
+	expected := "<1>\n  <1>1</1>\n  <2>2</2>\n</1>"
+	assert.Equal(t, expected, result)
+}
+
+func TestEmptyTypedCollectionsAreOmitted(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"title": "Document",
+		"tags":  []string{},
+		"meta":  map[string]int{},
+	})
+	expected := "<meta></meta>\n<title>Document</title>"
+	assert.Equal(t, expected, result)
+}
+
+type Level uint8
+
+func (l Level) String() string {
+	return [...]string{"debug", "info", "warn"}[l]
+}
+
+func TestUint8SlicesAndArrays(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"raw":    []uint8("ok"),
+		"array":  [2]uint8{'h', 'i'},
+		"levels": []Level{1, 2},
+	})
+	expected := "<array>hi</array>\n" +
+		"<levels>\n" +
+		"  <levels-1>info</levels-1>\n" +
+		"  <levels-2>warn</levels-2>\n" +
+		"</levels>\n" +
+		"<raw>ok</raw>"
+	assert.Equal(t, expected, result)
+}
+
+func TestUint8SlicesWithoutBytesRule(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"raw": []uint8{0xde, 0xad}}, llml.Options{
+		Registry: llml.NewRegistry(),
+		Bytes:    llml.BytesAsHex,
+	})
+	assert.Equal(t, "<raw>dead</raw>", result)
+}
@@ -0,0 +1,139 @@
+package llml_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type Email string
+
+type Address struct {
+	Street string `llml:"street"`
+	City   string `llml:"city"`
+}
+
+func isEmail(value any) bool {
+	_, ok := value.(Email)
+	return ok
+}
+
+func formatEmail(value any, _ llml.Options) string {
+	return "mailto:" + strings.ToLower(string(value.(Email)))
+}
+
+func isAddress(value any) bool {
+	_, ok := value.(Address)
+	return ok
+}
+
+func formatAddress(value any, _ llml.Options) string {
+	a := value.(Address)
+	return a.Street + ", " + a.City
+}
+
+func TestDefaultRegistryMatchesBuiltins(t *testing.T) {
+	data := map[string]any{
+		"name":   "  Alice  ",
+		"active": true,
+		"count":  int8(3),
+		"size":   uint32(7),
+		"score":  float32(0.5),
+	}
+	assert.Equal(t, llml.Sprintf(data), llml.Sprintf(data, llml.Options{Registry: llml.DefaultRegistry()}))
+	assert.Equal(t,
+		"<active>true</active>\n<count>3</count>\n<name>Alice</name>\n<score>0.5</score>\n<size>7</size>",
+		llml.Sprintf(data))
+}
+
+func TestCustomFormatterForDomainType(t *testing.T) {
+	registry := llml.DefaultRegistry().Register(isEmail, formatEmail)
+	result := llml.Sprintf(map[string]any{
+		"contact": Email("Alice@Example.com"),
+		"name":    "Alice",
+	}, llml.Options{Registry: registry})
+	expected := "<contact>mailto:alice@example.com</contact>\n<name>Alice</name>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCustomFormatterOverridesStructTraversal(t *testing.T) {
+	registry := llml.DefaultRegistry().Register(isAddress, formatAddress)
+	result := llml.Sprintf(map[string]any{
+		"shipping": Address{Street: "1 Main St", City: "Springfield"},
+	}, llml.Options{Registry: registry})
+	expected := "<shipping>1 Main St, Springfield</shipping>"
+	assert.Equal(t, expected, result)
+}
+
+func TestCustomFormatterOverridesDefaults(t *testing.T) {
+	shout := func(value any, _ llml.Options) string {
+		return strings.ToUpper(value.(string))
+	}
+	isString := func(value any) bool {
+		_, ok := value.(string)
+		return ok
+	}
+	registry := llml.DefaultRegistry().Register(isString, shout)
+	result := llml.Sprintf([]any{"quiet", 1}, llml.Options{Registry: registry})
+	expected := "<1>QUIET</1>\n<2>1</2>"
+	assert.Equal(t, expected, result)
+}
+
+func TestLaterRegistrationsTakePrecedence(t *testing.T) {
+	first := func(any, llml.Options) string { return "first" }
+	second := func(any, llml.Options) string { return "second" }
+	registry := llml.NewRegistry().Register(isEmail, first).Register(isEmail, second)
+	assert.Equal(t, "second", llml.Sprintf(Email("x"), llml.Options{Registry: registry}))
+}
+
+func TestRegistryAppliesAtDepth(t *testing.T) {
+	registry := llml.DefaultRegistry().Register(isEmail, formatEmail)
+	result := llml.Sprintf(map[string]any{
+		"team": map[string]any{
+			"members": []any{
+				map[string]any{"email": Email("Bob@Example.com")},
+			},
+		},
+	}, llml.Options{Registry: registry})
+	assert.Contains(t, result, "<email>mailto:bob@example.com</email>")
+}
+
+func TestFormatterReceivesOptions(t *testing.T) {
+	var seen []string
+	record := func(value any, opts llml.Options) string {
+		seen = append(seen, opts.Indent)
+		return string(value.(Email))
+	}
+	registry := llml.DefaultRegistry().Register(isEmail, record)
+	llml.Sprintf(map[string]any{
+		"outer": map[string]any{"email": Email("a@b.c")},
+	}, llml.Options{Registry: registry})
+	assert.Equal(t, []string{"  "}, seen)
+}
+
+func TestEmptyRegistryFallsBackToDefaultFormatting(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"count": 42}, llml.Options{Registry: llml.NewRegistry()})
+	expected := "<count>42</count>"
+	assert.Equal(t, expected, result)
+}
+
+type Name string
+
+type Shouted string
+
+func (s Shouted) String() string {
+	return strings.ToUpper(string(s))
+}
+
+func TestNamedStringTypesRenderLikeStrings(t *testing.T) {
+	multiline := "  first\n  second  "
+	plain := llml.Sprintf(map[string]any{"bio": map[string]any{"text": multiline}})
+	named := llml.Sprintf(map[string]any{"bio": map[string]any{"text": Name(multiline)}})
+	assert.Equal(t, plain, named)
+	assert.NotContains(t, named, "second  ")
+
+	// Named strings with a method of their own render through it
+	assert.Equal(t, "<word>HI</word>", llml.Sprintf(map[string]any{"word": Shouted("hi")}))
+}
@@ -0,0 +1,129 @@
+package llml_test
+
+import (
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type APIUser struct {
+	UserID   int    `json:"user_id"`
+	Email    string `json:"email,omitempty" xml:"mail"`
+	Nickname string `xml:"nick,omitempty"`
+	Password string `json:"-"`
+	Role     string `llml:"role" json:"user_role"`
+}
+
+var tagFallbackOptions = llml.Options{TagKeys: []string{"llml", "json", "xml"}}
+
+func TestJSONTagsIgnoredByDefault(t *testing.T) {
+	result := llml.Sprintf(APIUser{UserID: 7, Email: "a@b.c", Role: "admin"})
+	expected := "<UserID>7</UserID>\n" +
+		"<Email>a@b.c</Email>\n" +
+		"<Nickname></Nickname>\n" +
+		"<Password></Password>\n" +
+		"<role>admin</role>"
+	assert.Equal(t, expected, result)
+}
+
+func TestJSONAndXMLTagFallback(t *testing.T) {
+	result := llml.Sprintf(APIUser{
+		UserID:   7,
+		Email:    "a@b.c",
+		Nickname: "al",
+		Password: "hunter2",
+		Role:     "admin",
+	}, tagFallbackOptions)
+	expected := "<user_id>7</user_id>\n" +
+		"<email>a@b.c</email>\n" +
+		"<nick>al</nick>\n" +
+		"<role>admin</role>"
+	assert.Equal(t, expected, result)
+}
+
+func TestJSONTagOmitEmpty(t *testing.T) {
+	result := llml.Sprintf(APIUser{UserID: 1}, tagFallbackOptions)
+	expected := "<user_id>1</user_id>\n<role></role>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTagPrecedenceOrder(t *testing.T) {
+	result := llml.Sprintf(APIUser{Email: "a@b.c", Role: "admin"}, llml.Options{
+		TagKeys: []string{"xml", "json", "llml"},
+	})
+	expected := "<user_id>0</user_id>\n" +
+		"<mail>a@b.c</mail>\n" +
+		"<user_role>admin</user_role>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTagKeysPropagateToNestedStructs(t *testing.T) {
+	type Team struct {
+		Members []APIUser `json:"members"`
+		Lead    APIUser   `json:"lead"`
+	}
+	result := llml.Sprintf(map[string]any{
+		"team": Team{
+			Members: []APIUser{{UserID: 2, Role: "dev"}},
+			Lead:    APIUser{UserID: 1, Role: "lead"},
+		},
+	}, tagFallbackOptions)
+	expected := "<team>\n" +
+		"  <members>\n" +
+		"    <members-1>\n" +
+		"      <user_id>2</user_id>\n" +
+		"      <role>dev</role>\n" +
+		"    </members-1>\n" +
+		"  </members>\n" +
+		"  <lead>\n" +
+		"    <user_id>1</user_id>\n" +
+		"    <role>lead</role>\n" +
+		"  </lead>\n" +
+		"</team>"
+	assert.Equal(t, expected, result)
+}
+
+type XMLContact struct {
+	XMLName xml.Name `xml:"contact"`
+	ID      int      `xml:"id,attr"`
+	Name    string   `xml:"name"`
+	City    string   `xml:"address>city"`
+	Zip     string   `xml:"address>zip,omitempty"`
+	Lat     float64  `xml:"address>geo>lat"`
+	Note    string   `xml:",chardata"`
+	Comment string   `xml:",comment"`
+	Phone   string   `xml:"phone"`
+}
+
+func TestXMLTagSyntax(t *testing.T) {
+	result := llml.Sprintf(XMLContact{
+		ID:      7,
+		Name:    "Ada",
+		City:    "London",
+		Lat:     51.5,
+		Note:    "prefers email",
+		Comment: "internal",
+		Phone:   "555",
+	}, llml.Options{TagKeys: []string{"xml"}})
+	expected := "<id>7</id>\n" +
+		"<name>Ada</name>\n" +
+		"<address>\n" +
+		"  <city>London</city>\n" +
+		"  <geo>    <lat>51.5</lat></geo>\n" +
+		"</address>\n" +
+		"<Note>prefers email</Note>\n" +
+		"<phone>555</phone>"
+	assert.Equal(t, expected, result)
+	assert.NotContains(t, result, ">b>")
+}
+
+func TestXMLNestingOnlyAppliesToXMLTags(t *testing.T) {
+	type Field struct {
+		Value string `json:"a>b"`
+	}
+	_, err := llml.MarshalString(Field{Value: "v"}, llml.Options{TagKeys: []string{"json"}})
+	var tagErr *llml.InvalidTagNameError
+	assert.ErrorAs(t, err, &tagErr)
+}
@@ -0,0 +1,129 @@
+package llml_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type Document struct {
+	Title   string  `llml:"title"`
+	Content string  `llml:"content"`
+	Score   float64 `llml:"relevance_score"`
+}
+
+type Request struct {
+	Task     string     `llml:"task"`
+	Rules    []string   `llml:"rules,omitempty"`
+	Docs     []Document `llml:"documents,omitempty"`
+	Internal string     `llml:"-"`
+	Note     string     `llml:"note,omitempty"`
+	secret   string
+}
+
+func TestStructFieldsInDeclarationOrder(t *testing.T) {
+	result := llml.Sprintf(Document{Title: "Guide", Content: "OAuth", Score: 0.95})
+	expected := "<title>Guide</title>\n<content>OAuth</content>\n<relevance_score>0.95</relevance_score>"
+	assert.Equal(t, expected, result)
+}
+
+func TestStructFieldNameFallback(t *testing.T) {
+	type Config struct {
+		Debug   bool
+		Timeout int
+	}
+	result := llml.Sprintf(Config{Debug: true, Timeout: 30})
+	expected := "<Debug>true</Debug>\n<Timeout>30</Timeout>"
+	assert.Equal(t, expected, result)
+}
+
+func TestStructSkipsDashAndUnexportedFields(t *testing.T) {
+	result := llml.Sprintf(Request{
+		Task:     "summarize",
+		Internal: "do not render",
+		secret:   "hidden",
+	})
+	expected := "<task>summarize</task>"
+	assert.Equal(t, expected, result)
+}
+
+func TestStructOmitEmpty(t *testing.T) {
+	type Flags struct {
+		Name    string `llml:"name,omitempty"`
+		Count   int    `llml:"count,omitempty"`
+		Enabled bool   `llml:"enabled,omitempty"`
+		Kept    int    `llml:"kept"`
+	}
+	result := llml.Sprintf(Flags{})
+	expected := "<kept>0</kept>"
+	assert.Equal(t, expected, result)
+}
+
+func TestStructTagWithOnlyOptions(t *testing.T) {
+	type Item struct {
+		Label string `llml:",omitempty"`
+	}
+	assert.Equal(t, "<Label>x</Label>", llml.Sprintf(Item{Label: "x"}))
+	assert.Equal(t, "", llml.Sprintf(Item{}))
+}
+
+func TestStructDashNameWithComma(t *testing.T) {
+	type Item struct {
+		Dash string `llml:"-,"`
+	}
+	assert.Equal(t, "<->x</->", llml.Sprintf(Item{Dash: "x"}))
+}
+
+func TestNestedStructsAndSlices(t *testing.T) {
+	result := llml.Sprintf(Request{
+		Task:  "answer",
+		Rules: []string{"cite sources"},
+		Docs: []Document{
+			{Title: "Guide", Content: "OAuth", Score: 0.95},
+		},
+	})
+	expected := "<task>answer</task>\n" +
+		"<rules>\n" +
+		"  <rules-1>cite sources</rules-1>\n" +
+		"</rules>\n" +
+		"<documents>\n" +
+		"  <documents-1>\n" +
+		"    <title>Guide</title>\n" +
+		"    <content>OAuth</content>\n" +
+		"    <relevance_score>0.95</relevance_score>\n" +
+		"  </documents-1>\n" +
+		"</documents>"
+	assert.Equal(t, expected, result)
+}
+
+func TestStructInsideMap(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"doc": Document{Title: "FAQ", Content: "Limits", Score: 0.5},
+	}, llml.Options{Strict: true})
+	expected := "<doc>\n" +
+		"  <doc-title>FAQ</doc-title>\n" +
+		"  <doc-content>Limits</doc-content>\n" +
+		"  <doc-relevance_score>0.5</doc-relevance_score>\n" +
+		"</doc>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDirectSliceOfStructs(t *testing.T) {
+	type Person struct {
+		Name string `llml:"name"`
+	}
+	result := llml.Sprintf([]Person{{Name: "Alice"}, {Name: "Bob"}})
+	expected := "<1>\n  <1-name>Alice</1-name>\n</1>\n<2>\n  <2-name>Bob</2-name>\n</2>"
+	assert.Equal(t, expected, result)
+}
+
+func TestStructMatchesEquivalentMapShape(t *testing.T) {
+	type Config struct {
+		Debug   bool `llml:"debug"`
+		Timeout int  `llml:"timeout"`
+	}
+	fromStruct := llml.Sprintf(map[string]any{"config": Config{Debug: true, Timeout: 30}})
+	fromMap := llml.Sprintf(map[string]any{"config": map[string]any{"debug": true, "timeout": 30}})
+	assert.Equal(t, fromMap, fromStruct)
+}
@@ -0,0 +1,105 @@
+package llml_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+var sanitized = llml.Options{TagNames: llml.TagPolicy{Replacement: "-"}}
+
+func TestTagNamesVerbatimByDefault(t *testing.T) {
+	assert.Equal(t, "<key with spaces>value</key with spaces>", llml.Sprintf(map[string]any{"key with spaces": "value"}))
+}
+
+func TestTagNamesReplaceAndCollapse(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"key  with\tspaces": 1,
+		" padded ":          2,
+		"a<b>&c":            3,
+		"path/to=x":         4,
+	}, sanitized)
+	expected := "<padded>2</padded>\n" +
+		"<a-b-c>3</a-b-c>\n" +
+		"<key-with-spaces>1</key-with-spaces>\n" +
+		"<path-to-x>4</path-to-x>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTagNamesEmptyKey(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"": "blank", "   ": "spaces"}, llml.Options{TagNames: llml.TagPolicy{Replacement: "_"}})
+	assert.Equal(t, "<_>blank</_>\n<_>spaces</_>", result)
+}
+
+func TestTagNamesEmptyKeyWithDashReplacement(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"": "blank", "<>": "markup"}, sanitized)
+	assert.Equal(t, "<_>blank</_>\n<_>markup</_>", result)
+
+	custom := llml.Options{TagNames: llml.TagPolicy{Replacement: "-", StartPrefix: "k"}}
+	assert.Equal(t, "<k>blank</k>", llml.Sprintf(map[string]any{"": "blank"}, custom))
+}
+
+func TestTagNamesInvalidStartCharacters(t *testing.T) {
+	result := llml.Sprintf(llml.KV(
+		"-flag", 1,
+		".hidden", 2,
+		"@user", 3,
+		"_ok", 4,
+		"été", 5,
+	), sanitized)
+	expected := "<_-flag>1</_-flag>\n" +
+		"<_.hidden>2</_.hidden>\n" +
+		"<_@user>3</_@user>\n" +
+		"<_ok>4</_ok>\n" +
+		"<été>5</été>"
+	assert.Equal(t, expected, result)
+
+	_, err := llml.MarshalString(map[string]any{"-flag": 1})
+	var tagErr *llml.InvalidTagNameError
+	assert.ErrorAs(t, err, &tagErr)
+}
+
+func TestTagNamesDigitPrefix(t *testing.T) {
+	opts := llml.Options{TagNames: llml.TagPolicy{DigitPrefix: "_"}}
+	result := llml.Sprintf(map[string]any{
+		"2fa":   true,
+		"steps": []string{"login"},
+	}, opts)
+	expected := "<_2fa>true</_2fa>\n<steps>\n  <steps-1>login</steps-1>\n</steps>"
+	assert.Equal(t, expected, result)
+
+	assert.Equal(t, "<_1>a</_1>\n<_2>b</_2>", llml.Sprintf([]string{"a", "b"}, opts))
+}
+
+func TestTagNamesApplyToNestedAndPrefixedTags(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"user info": map[string]any{"first name": "Ada"},
+		"tag list":  []string{"x"},
+	}, llml.Options{TagNames: llml.TagPolicy{Replacement: "-"}, Prefix: "my app", Strict: true})
+	expected := "<my-app-tag-list>\n" +
+		"  <my-app-tag-list-1>x</my-app-tag-list-1>\n" +
+		"</my-app-tag-list>\n" +
+		"<my-app-user-info>  <my-app-user-info-first-name>Ada</my-app-user-info-first-name></my-app-user-info>"
+	assert.Equal(t, expected, result)
+}
+
+func TestSanitizedUserJSONMarshalsCleanly(t *testing.T) {
+	var data map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(`{"First Name": "Ada", "e-mail <work>": "ada@example.com"}`), &data))
+
+	_, err := llml.MarshalString(data)
+	var tagErr *llml.InvalidTagNameError
+	assert.True(t, errors.As(err, &tagErr))
+
+	out, err := llml.MarshalString(data, sanitized)
+	assert.NoError(t, err)
+	assert.Equal(t, "<First-Name>Ada</First-Name>\n<e-mail-work>ada@example.com</e-mail-work>", out)
+}
+
+func TestTagNamesAfterKeyCase(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"user/name": "ada"}, llml.Options{KeyCase: llml.SnakeCase, TagNames: llml.TagPolicy{Replacement: "_"}})
+	assert.Equal(t, "<user_name>ada</user_name>", result)
+}
@@ -0,0 +1,112 @@
+package llml_test
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+type Priority int
+
+const (
+	PriorityLow Priority = iota
+	PriorityHigh
+)
+
+func (p Priority) String() string {
+	if p == PriorityHigh {
+		return "high"
+	}
+	return "low"
+}
+
+type Region struct {
+	Code string
+}
+
+func (r Region) MarshalText() ([]byte, error) {
+	return []byte("region:" + r.Code), nil
+}
+
+func (r Region) String() string {
+	return "Region(" + r.Code + ")"
+}
+
+type BadText struct {
+	Name string
+}
+
+func (BadText) MarshalText() ([]byte, error) {
+	return nil, errors.New("not today")
+}
+
+func TestStringerEnum(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"priority": PriorityHigh})
+	expected := "<priority>high</priority>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTextMarshalerStruct(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"region": Region{Code: "eu"}})
+	expected := "<region>region:eu</region>"
+	assert.Equal(t, expected, result)
+}
+
+func TestNetIPUsesTextMarshaler(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"ip": net.ParseIP("10.0.0.1")})
+	expected := "<ip>10.0.0.1</ip>"
+	assert.Equal(t, expected, result)
+}
+
+func TestURLPointerUsesStringer(t *testing.T) {
+	u, err := url.Parse("https://example.com/docs?q=1")
+	assert.NoError(t, err)
+	result := llml.Sprintf(map[string]any{"source": u})
+	expected := "<source>https://example.com/docs?q=1</source>"
+	assert.Equal(t, expected, result)
+}
+
+func TestErrorValues(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"errors": []error{errors.New("timeout"), errors.New("refused")},
+	})
+	expected := "<errors>\n  <errors-1>timeout</errors-1>\n  <errors-2>refused</errors-2>\n</errors>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTextMethodPriority(t *testing.T) {
+	region := map[string]any{"region": Region{Code: "us"}}
+	assert.Equal(t, "<region>Region(us)</region>", llml.Sprintf(region, llml.Options{
+		TextMethods: []llml.TextMethod{llml.UseStringer, llml.UseTextMarshaler},
+	}))
+}
+
+func TestTextMethodsDisabled(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"region": Region{Code: "us"}}, llml.Options{
+		TextMethods: []llml.TextMethod{},
+	})
+	expected := "<region>  <Code>us</Code></region>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTextMethodsApplyInNestedStructs(t *testing.T) {
+	type Ticket struct {
+		Priority Priority `llml:"priority"`
+		Region   Region   `llml:"region"`
+	}
+	result := llml.Sprintf([]Ticket{{Priority: PriorityLow, Region: Region{Code: "ap"}}})
+	expected := "<1>\n  <1-priority>low</1-priority>\n  <1-region>region:ap</1-region>\n</1>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTextMarshalerErrorFallsBack(t *testing.T) {
+	data := map[string]any{"item": BadText{Name: "x"}}
+	assert.Equal(t, "<item>  <Name>x</Name></item>", llml.Sprintf(data))
+
+	_, err := llml.MarshalString(data)
+	assert.EqualError(t, err, "llml: cannot marshal $.item: not today")
+}
@@ -0,0 +1,130 @@
+package llml_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zenbase-ai/llml/go/pkg/llml"
+)
+
+var deployedAt = time.Date(2024, time.March, 5, 14, 30, 0, 0, time.FixedZone("CET", 3600))
+
+func TestTimeDefaultsToRFC3339(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"last_deployment": deployedAt})
+	expected := "<last_deployment>2024-03-05T14:30:00+01:00</last_deployment>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTimeDropsMonotonicClock(t *testing.T) {
+	now := time.Now()
+	result := llml.Sprintf(map[string]any{"now": now})
+	assert.NotContains(t, result, "m=+")
+	assert.Equal(t, "<now>"+now.Format(time.RFC3339)+"</now>", result)
+}
+
+func TestTimeDateOnlyLayout(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"due": deployedAt}, llml.Options{TimeLayout: time.DateOnly})
+	expected := "<due>2024-03-05</due>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTimeCustomLayout(t *testing.T) {
+	result := llml.Sprintf(map[string]any{"due": deployedAt}, llml.Options{TimeLayout: "Jan 2, 2006 15:04 MST"})
+	expected := "<due>Mar 5, 2024 14:30 CET</due>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTimeZoneNormalisation(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"events": []time.Time{deployedAt, deployedAt.Add(90 * time.Minute)},
+	}, llml.Options{TimeZone: time.UTC})
+	expected := "<events>\n" +
+		"  <events-1>2024-03-05T13:30:00Z</events-1>\n" +
+		"  <events-2>2024-03-05T15:00:00Z</events-2>\n" +
+		"</events>"
+	assert.Equal(t, expected, result)
+}
+
+func TestTimePointersAndStructFields(t *testing.T) {
+	type Release struct {
+		Version    string     `llml:"version"`
+		ReleasedAt *time.Time `llml:"released_at"`
+	}
+	result := llml.Sprintf(Release{Version: "1.2.0", ReleasedAt: &deployedAt}, llml.Options{TimeZone: time.UTC})
+	expected := "<version>1.2.0</version>\n<released_at>2024-03-05T13:30:00Z</released_at>"
+	assert.Equal(t, expected, result)
+}
+
+func TestDurationStyles(t *testing.T) {
+	data := map[string]any{"timeout": 90*time.Minute + 500*time.Millisecond}
+	assert.Equal(t, "<timeout>1h30m0.5s</timeout>", llml.Sprintf(data))
+	assert.Equal(t, "<timeout>5400.5</timeout>", llml.Sprintf(data, llml.Options{Durations: llml.DurationAsSeconds}))
+	assert.Equal(t, "<timeout>PT1H30M0.5S</timeout>", llml.Sprintf(data, llml.Options{Durations: llml.DurationAsISO8601}))
+}
+
+func TestDurationISO8601EdgeCases(t *testing.T) {
+	opts := llml.Options{Durations: llml.DurationAsISO8601}
+	assert.Equal(t, "PT0S", llml.Sprintf(time.Duration(0), opts))
+	assert.Equal(t, "-PT2M", llml.Sprintf(-2*time.Minute, opts))
+	assert.Equal(t, "PT48H", llml.Sprintf(48*time.Hour, opts))
+}
+
+func fixedClock() time.Time {
+	return time.Date(2024, time.March, 5, 16, 45, 0, 0, time.UTC)
+}
+
+func TestRelativeTimes(t *testing.T) {
+	opts := llml.Options{Times: llml.TimeAsRelative, Now: fixedClock}
+	now := fixedClock()
+	cases := map[time.Time]string{
+		now:                                 "just now",
+		now.Add(-45 * time.Second):          "45 seconds ago",
+		now.Add(-time.Minute):               "1 minute ago",
+		now.Add(-3*time.Hour - time.Minute): "3 hours ago",
+		now.Add(-49 * time.Hour):            "2 days ago",
+		now.Add(-65 * 24 * time.Hour):       "2 months ago",
+		now.Add(-800 * 24 * time.Hour):      "2 years ago",
+		now.Add(26 * time.Hour):             "in 1 day",
+	}
+	for at, expected := range cases {
+		assert.Equal(t, expected, llml.Sprintf(at, opts))
+	}
+}
+
+func TestRelativeTimesAtDepth(t *testing.T) {
+	result := llml.Sprintf(map[string]any{
+		"deployments": []any{
+			map[string]any{"service": "api", "finished_at": deployedAt},
+		},
+	}, llml.Options{Times: llml.TimeAsRelative, Now: fixedClock})
+	assert.Contains(t, result, "<finished_at>3 hours ago</finished_at>")
+}
+
+func TestRelativeTimeWithAbsoluteAttribute(t *testing.T) {
+	opts := llml.Options{Times: llml.TimeAsRelativeWithAbsolute, Now: fixedClock, TimeZone: time.UTC}
+	result := llml.Sprintf(map[string]any{
+		"last_deployment": &deployedAt,
+		"history":         []time.Time{deployedAt},
+	}, opts)
+	expected := "<history>\n" +
+		"  <history-1 datetime=\"2024-03-05T13:30:00Z\">3 hours ago</history-1>\n" +
+		"</history>\n" +
+		"<last_deployment datetime=\"2024-03-05T13:30:00Z\">3 hours ago</last_deployment>"
+	assert.Equal(t, expected, result)
+
+	direct := llml.Sprintf([]time.Time{deployedAt}, opts)
+	assert.Equal(t, "<1 datetime=\"2024-03-05T13:30:00Z\">3 hours ago</1>", direct)
+}
+
+func TestRelativeTimeAttributeOnWrappedValues(t *testing.T) {
+	opts := llml.Options{Times: llml.TimeAsRelativeWithAbsolute, Now: fixedClock, TimeZone: time.UTC, Rand: rand.New(rand.NewSource(1))}
+	result := llml.Sprintf(llml.KV(
+		"seen", llml.Untrusted(deployedAt),
+		"since", llml.Fenced(llml.Untrusted(&deployedAt)),
+	), opts)
+	expected := "<seen datetime=\"2024-03-05T13:30:00Z\">3 hours ago</seen>\n" +
+		"<since-52fdfc07 datetime=\"2024-03-05T13:30:00Z\">3 hours ago</since-52fdfc07>"
+	assert.Equal(t, expected, result)
+}
@@ -100,10 +100,11 @@ func TestFloat32Type(t *testing.T) {
 }
 
 func TestUnknownType(t *testing.T) {
+	// Structs without exported fields are opaque and fall back to %v
 	type CustomStruct struct {
-		Name string
+		name string
 	}
-	val := CustomStruct{Name: "test"}
+	val := CustomStruct{name: "test"}
 	result := llml.Sprintf(map[string]any{
 		"value": val,
 	})